@@ -272,7 +272,17 @@ type (
 		Value    []Expr
 		Paren    bool      // list is wrapped in parenthesis
 		Comma    bool      // record if list was comma delimited
+		Bracket  bool      // list is wrapped in square brackets
 		EndPos   token.Pos // end of list
+		// keyword arguments passed to a variadic argument ie. $args...
+		Keywords []*KeyValueExpr
+	}
+
+	// A MapLit node represents a map ie. (key: value, key2: value2)
+	MapLit struct {
+		Lparen token.Pos // position of "("
+		Value  []*KeyValueExpr
+		Rparen token.Pos // position of ")"
 	}
 
 	// A FuncLit node represents a function literal.
@@ -470,6 +480,7 @@ func (x *Interp) Pos() token.Pos   { return x.Lbrace }
 func (x *Ellipsis) Pos() token.Pos { return x.Ellipsis }
 func (x *BasicLit) Pos() token.Pos { return x.ValuePos }
 func (x *ListLit) Pos() token.Pos  { return x.ValuePos }
+func (x *MapLit) Pos() token.Pos   { return x.Lparen }
 func (x *FuncLit) Pos() token.Pos  { return x.Type.Pos() }
 func (x *CompositeLit) Pos() token.Pos {
 	if x.Type != nil {
@@ -516,6 +527,7 @@ func (x *Ellipsis) End() token.Pos {
 }
 func (x *BasicLit) End() token.Pos       { return token.Pos(int(x.ValuePos) + len(x.Value)) }
 func (x *ListLit) End() token.Pos        { return x.EndPos }
+func (x *MapLit) End() token.Pos         { return x.Rparen + 1 }
 func (x *FuncLit) End() token.Pos        { return x.Body.End() }
 func (x *CompositeLit) End() token.Pos   { return x.Rbrace + 1 }
 func (x *StringExpr) End() token.Pos     { return x.Rquote + 1 }
@@ -551,6 +563,7 @@ func (*Interp) exprNode()         {}
 func (*Ellipsis) exprNode()       {}
 func (*BasicLit) exprNode()       {}
 func (*ListLit) exprNode()        {}
+func (*MapLit) exprNode()         {}
 func (*FuncLit) exprNode()        {}
 func (*CompositeLit) exprNode()   {}
 func (*StringExpr) exprNode()     {}
@@ -792,6 +805,7 @@ type (
 	EachStmt struct {
 		Each  token.Pos // position of @each
 		X     *Ident    // iterator
+		Value *Ident    // optional value iterator ie. @each $key, $value
 		Range []Expr
 		Body  *BlockStmt
 		List  []Expr // List of values for the iterator
@@ -809,6 +823,38 @@ type (
 		Query *BasicLit
 		Body  *BlockStmt
 	}
+
+	// An ExtendStmt represents @extend
+	ExtendStmt struct {
+		Extend   token.Pos // position of @extend
+		Sel      *BasicLit // target selector
+		Optional bool      // !optional was set
+		Parent   *SelStmt  // selector @extend was found in
+	}
+
+	// A DebugStmt represents @debug, @warn or @error
+	DebugStmt struct {
+		TokPos token.Pos   // position of Tok
+		Tok    token.Token // DEBUG, WARN or ERROR
+		X      Expr        // value to report
+	}
+
+	// An AtRuleStmt represents an at-rule passed through to CSS
+	// ie. @supports, @keyframes or @font-face
+	AtRuleStmt struct {
+		AtPos token.Pos  // position of the at-rule name
+		Name  string     // at-rule name ie. @supports
+		Rule  *BasicLit  // name and prelude ie. @supports (display: grid)
+		Body  *BlockStmt // nil when terminated by ;
+	}
+
+	// An AtRootStmt represents @at-root, the rules in Body are
+	// printed outside of the parent selectors
+	AtRootStmt struct {
+		AtRoot token.Pos  // position of "@at-root"
+		Query  *BasicLit  // ie. (without: media); or nil
+		Body   *BlockStmt // rules moved to the root
+	}
 )
 
 // Pos and End implementations for statement nodes.
@@ -840,6 +886,10 @@ func (s *SelStmt) Pos() token.Pos     { return s.NamePos }
 func (s *IncludeStmt) Pos() token.Pos { return s.Spec.Pos() }
 func (s *MediaStmt) Pos() token.Pos   { return s.Name.Pos() }
 func (s *EachStmt) Pos() token.Pos    { return s.Each }
+func (s *ExtendStmt) Pos() token.Pos  { return s.Extend }
+func (s *DebugStmt) Pos() token.Pos   { return s.TokPos }
+func (s *AtRuleStmt) Pos() token.Pos  { return s.AtPos }
+func (s *AtRootStmt) Pos() token.Pos  { return s.AtRoot }
 func (s *BadStmt) End() token.Pos     { return s.To }
 func (s *DeclStmt) End() token.Pos    { return s.Decl.End() }
 func (s *EmptyStmt) End() token.Pos {
@@ -899,6 +949,38 @@ func (s *SelStmt) End() token.Pos     { return s.Body.End() }
 func (s *IncludeStmt) End() token.Pos { return s.Spec.End() }
 func (s *MediaStmt) End() token.Pos   { return s.Body.End() }
 func (s *EachStmt) End() token.Pos    { return s.Body.End() }
+func (s *ExtendStmt) End() token.Pos  { return s.Sel.End() }
+func (s *DebugStmt) End() token.Pos   { return s.X.End() }
+func (s *AtRuleStmt) End() token.Pos {
+	if s.Body != nil {
+		return s.Body.End()
+	}
+	return s.Rule.End()
+}
+func (s *AtRootStmt) End() token.Pos { return s.Body.End() }
+
+// Excludes reports whether the rules of s are moved outside of name,
+// ie. rule or media. Without a query only rule is excluded.
+func (s *AtRootStmt) Excludes(name string) bool {
+	if s.Query == nil {
+		return name == "rule"
+	}
+	q := strings.Trim(s.Query.Value, "()")
+	i := strings.Index(q, ":")
+	if i < 0 {
+		return name == "rule"
+	}
+	var found bool
+	for _, v := range strings.Fields(q[i+1:]) {
+		if v == name || v == "all" {
+			found = true
+		}
+	}
+	if strings.TrimSpace(q[:i]) == "with" {
+		return !found
+	}
+	return found
+}
 
 // stmtNode() ensures that only statement nodes can be
 // assigned to a Stmt.
@@ -929,6 +1011,10 @@ func (*SelStmt) stmtNode()        {}
 func (*EachStmt) stmtNode()       {}
 func (*IncludeStmt) stmtNode()    {}
 func (*MediaStmt) stmtNode()      {}
+func (*ExtendStmt) stmtNode()     {}
+func (*DebugStmt) stmtNode()      {}
+func (*AtRuleStmt) stmtNode()     {}
+func (*AtRootStmt) stmtNode()     {}
 
 // ----------------------------------------------------------------------------
 // Declarations
@@ -950,6 +1036,8 @@ type (
 		Path    *BasicLit     // import path
 		Comment *CommentGroup // line comments; or nil
 		EndPos  token.Pos     // end of spec (overrides Path.Pos if nonzero)
+		Media   *BasicLit     // media query of a CSS import; or nil
+		CSS     bool          // plain CSS import, output as is
 	}
 
 	// A ValueSpec node represents a constant or variable declaration
@@ -991,6 +1079,7 @@ type (
 		Name   *Ident
 		Params *FieldList // (incoming) parameters; or nil
 		List   []Stmt     // Statements contained in the mixin referred to by this include
+		Body   *BlockStmt // content block passed to the mixin; or nil
 	}
 )
 
@@ -1126,6 +1215,36 @@ type (
 	EachDecl struct {
 		*EachStmt
 	}
+
+	// A MediaDecl node represents a @media found outside of
+	// selectors
+	MediaDecl struct {
+		*MediaStmt
+	}
+
+	// A CommDecl node represents a comment found outside of
+	// selectors
+	CommDecl struct {
+		*CommStmt
+	}
+
+	// A DebugDecl node represents @debug, @warn or @error found
+	// outside of selectors
+	DebugDecl struct {
+		*DebugStmt
+	}
+
+	// An AtRuleDecl node represents a generic at-rule found outside
+	// of selectors
+	AtRuleDecl struct {
+		*AtRuleStmt
+	}
+
+	// An AtRootDecl node represents @at-root found outside of
+	// selectors
+	AtRootDecl struct {
+		*AtRootStmt
+	}
 )
 
 // Pos and End implementations for declaration nodes.
@@ -1160,11 +1279,16 @@ func (d *FuncDecl) End() token.Pos {
 // declNode() ensures that only declaration nodes can be
 // assigned to a Decl.
 //
-func (*BadDecl) declNode()  {}
-func (*GenDecl) declNode()  {}
-func (*FuncDecl) declNode() {}
-func (*SelDecl) declNode()  {}
-func (*IfDecl) declNode()   {}
+func (*BadDecl) declNode()    {}
+func (*GenDecl) declNode()    {}
+func (*FuncDecl) declNode()   {}
+func (*SelDecl) declNode()    {}
+func (*IfDecl) declNode()     {}
+func (*MediaDecl) declNode()  {}
+func (*CommDecl) declNode()   {}
+func (*DebugDecl) declNode()  {}
+func (*AtRuleDecl) declNode() {}
+func (*AtRootDecl) declNode() {}
 
 // ----------------------------------------------------------------------------
 // Files and packages
@@ -1182,7 +1306,9 @@ type File struct {
 	Decls      []Decl          // top-level declarations; or nil
 	Scope      *Scope          // package scope (this file only)
 	Imports    []*ImportSpec   // imports in this file
+	Includes   []*IncludeSpec  // @include found in this file
 	Unresolved []*Ident        // unresolved identifiers in this file
+	Unused     []*Ident        // variables never referenced in this file
 	Comments   []*CommentGroup // list of all comments in the source file
 }
 
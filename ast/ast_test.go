@@ -6,6 +6,8 @@ package ast
 
 import (
 	"testing"
+
+	"github.com/wellington/sass/token"
 )
 
 var comments = []struct {
@@ -48,3 +50,30 @@ func TestCommentText(t *testing.T) {
 		}
 	}
 }
+
+func TestAtRootStmt_Excludes(t *testing.T) {
+	tests := []struct {
+		query       string
+		rule, media bool
+	}{
+		{"", true, false},
+		{"(without: media)", false, true},
+		{"(without: rule)", true, false},
+		{"(without: all)", true, true},
+		{"(with: media)", true, false},
+		{"(with: rule)", false, true},
+		{"(with: all)", false, false},
+	}
+	for _, test := range tests {
+		stmt := &AtRootStmt{}
+		if len(test.query) > 0 {
+			stmt.Query = &BasicLit{Kind: token.STRING, Value: test.query}
+		}
+		if got := stmt.Excludes("rule"); got != test.rule {
+			t.Errorf("%q rule got: %t wanted: %t", test.query, got, test.rule)
+		}
+		if got := stmt.Excludes("media"); got != test.media {
+			t.Errorf("%q media got: %t wanted: %t", test.query, got, test.media)
+		}
+	}
+}
@@ -64,12 +64,20 @@ func Op(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
 			fn = floatOp
 		case token.UPCT:
 			fn = pctOp
+		default:
+			if y.Kind.IsCSSNum() {
+				fn = unitOp
+			}
 		}
 		// Other Kinds that could act as Float
 	case kind == token.FLOAT:
-		switch y.Kind {
-		case token.STRING:
+		switch {
+		case y.Kind == token.STRING:
 			fn = stringOp
+		case y.Kind == token.UPCT:
+			fn = pctOp
+		case y.Kind.IsCSSNum():
+			fn = unitOp
 		default:
 			fn = floatOp
 		}
@@ -77,7 +85,17 @@ func Op(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
 		fmt.Println("string op?", x.Value, y.Value)
 		fn = stringOp
 	case kind == token.UPCT:
-		fn = pctOp
+		switch {
+		case y.Kind == token.INT, y.Kind == token.FLOAT, y.Kind == token.UPCT:
+			fn = pctOp
+		case y.Kind.IsCSSNum():
+			fn = unitOp
+		}
+	case kind.IsCSSNum():
+		switch {
+		case y.Kind == token.INT, y.Kind == token.FLOAT, y.Kind.IsCSSNum():
+			fn = unitOp
+		}
 	}
 
 	// math operations do not happen unless explicity enforced
@@ -100,15 +118,6 @@ func Op(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
 	}
 
 	if fn == nil {
-
-		units := []token.Token{token.UEM, token.UPX}
-		for _, u := range units {
-			// case kind == token.UEM:
-			if x.Kind == u || y.Kind == u {
-				return otherOp(op, x, y, combine)
-			}
-		}
-
 		// no functions matched, check registered functions
 		for _, k := range kinds {
 			if k.unit == kind {
@@ -153,57 +162,6 @@ func pctOp(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
 	return lit, err
 }
 
-func otherOp(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
-	if !combine && op == token.QUO {
-		return stringOp(op, x, y, combine)
-	}
-
-	a, b := x, y
-	// Order for non-string operations is not important. Reorder for simpler logic
-	switch {
-	case x.Kind == token.INT || x.Kind == token.FLOAT:
-		a = y
-		b = x
-	case y.Kind == token.INT || y.Kind == token.FLOAT:
-	default:
-		if op == token.MUL {
-			return nil, fmt.Errorf("unsupported operation: %s %s %s", x, op, y)
-		}
-	}
-
-	var kind token.Token
-	switch a.Kind {
-	case token.ILLEGAL:
-	default:
-		kind = a.Kind
-	}
-
-	switch b.Kind {
-	case token.ILLEGAL:
-	default:
-		switch {
-		case kind == token.ILLEGAL:
-			kind = b.Kind
-		case b.Kind == token.INT || b.Kind == token.FLOAT:
-		case kind != b.Kind:
-			return nil, fmt.Errorf("illegal unit operation %s %s",
-				a.Kind, b.Kind)
-		}
-	}
-	xx := &BasicLit{
-		Value: strings.TrimSuffix(a.Value, a.Kind.String()),
-	}
-	yy := &BasicLit{
-		Value: strings.TrimSuffix(b.Value, b.Kind.String()),
-	}
-
-	f, err := floatOp(op, xx, yy, combine)
-	return &BasicLit{
-		Kind:  kind,
-		Value: f.Value + kind.String(),
-	}, err
-}
-
 func floatOp(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
 	out := &BasicLit{
 		Kind: token.FLOAT,
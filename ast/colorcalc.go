@@ -6,6 +6,7 @@ import (
 	"image/color"
 	"log"
 	"strconv"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/wellington/sass/token"
@@ -158,8 +159,32 @@ var cssColors = map[string]string{
 	"#663399": "rebeccapurple",
 }
 
+// colorNames maps CSS color names to hex, it is filled from
+// cssColors along with the aliases found here
+var colorNames = map[string]string{
+	"aqua":           "#00ffff",
+	"fuchsia":        "#ff00ff",
+	"grey":           "#808080",
+	"darkgrey":       "#a9a9a9",
+	"darkslategrey":  "#2f4f4f",
+	"dimgrey":        "#696969",
+	"lightgrey":      "#d3d3d3",
+	"lightslategrey": "#778899",
+	"slategrey":      "#708090",
+}
+
 func init() {
 	RegisterKind(colorOp, token.COLOR)
+	for hex, name := range cssColors {
+		colorNames[name] = hex
+	}
+}
+
+// LookupColorName finds the hex for a CSS color name, names are
+// case insensitive.
+func LookupColorName(name string) (string, bool) {
+	hex, ok := colorNames[strings.ToLower(name)]
+	return hex, ok
 }
 
 // LookupColor finds a CSS name for a hex, if available. Otherwise,
@@ -235,6 +260,10 @@ func colorFromRGBA(in string) color.RGBA {
 }
 
 func colorFromHex(in []byte) color.RGBA {
+	// names like red or orange would otherwise be read as hex
+	if hex, ok := LookupColorName(string(in)); ok {
+		in = []byte(hex)
+	}
 	pound, w := utf8.DecodeRune(in)
 	if pound == '#' {
 		in = in[w:]
@@ -247,17 +276,7 @@ func colorFromHex(in []byte) color.RGBA {
 	if len(in) != 6 {
 		// Shittttttt..... need better internal
 		// representation of colors
-		s := string(in)
-		var found bool
-		for key, color := range cssColors {
-			if s == color {
-				found = true
-				in = []byte(key)[1:]
-			}
-		}
-		if !found {
-			return colorFromRGBA(string(in))
-		}
+		return colorFromRGBA(string(in))
 	}
 
 	r, g, b := in[0:2], in[2:4], in[4:6]
@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/wellington/sass/token"
+)
+
+// Compare evaluates the equality and relational operators on x and y.
+// Equality is defined for all kinds, relational operators only
+// compare numbers with compatible units ie. 1in > 95px. Numbers are
+// compared at prec decimal places.
+func Compare(op token.Token, x, y *BasicLit, prec int) (*BasicLit, error) {
+	var b bool
+	switch op {
+	case token.EQL:
+		b = Equal(x, y, prec)
+	case token.NEQ:
+		b = !Equal(x, y, prec)
+	case token.LSS, token.GTR, token.LEQ, token.GEQ:
+		var err error
+		b, err = relational(op, x, y, prec)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported comparison %s", op)
+	}
+	return &BasicLit{
+		Kind:     token.BOOL,
+		Value:    strconv.FormatBool(b),
+		ValuePos: x.Pos(),
+	}, nil
+}
+
+// Equal reports whether x and y are the same value. Strings are equal
+// regardless of quotes, numbers must have the same or convertible units
+// ie. 1in == 96px and are compared at prec decimal places. Colors are
+// compared by their channels ie. red == #f00
+func Equal(x, y *BasicLit, prec int) bool {
+	switch {
+	case isString(x.Kind) && isString(y.Kind):
+		return x.Value == y.Value
+	case x.Kind == token.COLOR && y.Kind == token.COLOR:
+		cx, err := ColorFromHexString(x.Value)
+		if err != nil {
+			return false
+		}
+		cy, err := ColorFromHexString(y.Value)
+		if err != nil {
+			return false
+		}
+		return cx == cy
+	}
+	a, err := newNumber(x)
+	if err != nil {
+		return x.Kind == y.Kind && x.Value == y.Value
+	}
+	b, err := newNumber(y)
+	if err != nil || a.unit != b.unit &&
+		(a.unit == token.ILLEGAL || b.unit == token.ILLEGAL) {
+		return false
+	}
+	a, b, err = convert(x, y, a, b)
+	if err != nil {
+		return false
+	}
+	return round(a.f, prec) == round(b.f, prec)
+}
+
+func isString(kind token.Token) bool {
+	switch kind {
+	case token.STRING, token.QSTRING, token.QSSTRING:
+		return true
+	}
+	return false
+}
+
+// relational compares numbers, unitless numbers compare to any unit
+func relational(op token.Token, x, y *BasicLit, prec int) (bool, error) {
+	a, err := newNumber(x)
+	if err != nil {
+		return false, err
+	}
+	b, err := newNumber(y)
+	if err != nil {
+		return false, err
+	}
+	if a, b, err = convert(x, y, a, b); err != nil {
+		return false, fmt.Errorf("%s: %s %s %s", err, x.Value, op, y.Value)
+	}
+	af, bf := round(a.f, prec), round(b.f, prec)
+	switch op {
+	case token.LSS:
+		return af < bf, nil
+	case token.GTR:
+		return af > bf, nil
+	case token.LEQ:
+		return af <= bf, nil
+	}
+	return af >= bf, nil
+}
+
+// convert returns the numbers a and b of x and y in the same unit.
+// Numbers in different units are compared by their difference, so a
+// holds x - y and b is zero. Unitless numbers are left as is.
+func convert(x, y *BasicLit, a, b number) (number, number, error) {
+	if a.unit == b.unit ||
+		a.unit == token.ILLEGAL || b.unit == token.ILLEGAL {
+		return a, b, nil
+	}
+	fn := registeredKind(a.unit, b.unit)
+	if fn == nil || unitGroup(a.unit) != unitGroup(b.unit) {
+		return a, b, fmt.Errorf("incompatible units %s and %s",
+			unitSuffix(a.unit), unitSuffix(b.unit))
+	}
+	// compare x with y converted to the unit of x
+	d, err := fn(token.SUB, x, y, true)
+	if err != nil {
+		return a, b, err
+	}
+	if a, err = newNumber(d); err != nil {
+		return a, b, err
+	}
+	return a, number{unit: a.unit}, nil
+}
+
+// unitGroup returns the kind of dimension unit measures, only units
+// of the same group convert to each other ie. in and px, deg and rad
+func unitGroup(unit token.Token) string {
+	switch unit {
+	case token.UIN, token.UCM, token.UMM, token.UPC, token.UPX, token.UPT:
+		return "length"
+	case token.DEG, token.GRAD, token.RAD, token.TURN:
+		return "angle"
+	}
+	return unitSuffix(unit)
+}
@@ -0,0 +1,64 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/token"
+)
+
+func TestCompare(t *testing.T) {
+	lit := func(kind token.Token, val string) *BasicLit {
+		return &BasicLit{Kind: kind, Value: val}
+	}
+	tests := []struct {
+		x  *BasicLit
+		op token.Token
+		y  *BasicLit
+		e  string
+	}{
+		{lit(token.UPX, "1px"), token.EQL, lit(token.UPX, "1px"), "true"},
+		{lit(token.UPX, "1px"), token.EQL, lit(token.INT, "1"), "false"},
+		{lit(token.FLOAT, "1.0"), token.EQL, lit(token.INT, "1"), "true"},
+		{lit(token.QSTRING, "a"), token.EQL, lit(token.STRING, "a"), "true"},
+		{lit(token.COLOR, "red"), token.EQL, lit(token.COLOR, "#f00"), "true"},
+		{lit(token.COLOR, "red"), token.NEQ, lit(token.COLOR, "blue"), "true"},
+		{lit(token.BOOL, "true"), token.EQL, lit(token.STRING, "true"), "false"},
+		{lit(token.NULL, "null"), token.EQL, lit(token.NULL, "null"), "true"},
+		{lit(token.INT, "1"), token.LSS, lit(token.UPX, "2px"), "true"},
+		{lit(token.UPX, "2px"), token.LEQ, lit(token.UPX, "1px"), "false"},
+		{lit(token.UPCT, "50%"), token.GEQ, lit(token.UPCT, "50%"), "true"},
+		{lit(token.FLOAT, "0.30000000000000004"), token.EQL, lit(token.FLOAT, "0.3"), "true"},
+		{lit(token.FLOAT, "0.30001"), token.EQL, lit(token.FLOAT, "0.3"), "false"},
+		{lit(token.FLOAT, "0.300001"), token.GTR, lit(token.FLOAT, "0.3"), "false"},
+	}
+	for _, test := range tests {
+		out, err := Compare(test.op, test.x, test.y, DefaultPrecision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Kind != token.BOOL {
+			t.Errorf("got kind: %s wanted: %s", out.Kind, token.BOOL)
+		}
+		if out.Value != test.e {
+			t.Errorf("%s %s %s got: %s wanted: %s",
+				test.x.Value, test.op, test.y.Value, out.Value, test.e)
+		}
+	}
+
+	out, err := Compare(token.EQL, lit(token.FLOAT, "1.0001"), lit(token.INT, "1"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Value != "true" {
+		t.Errorf("1.0001 == 1 at precision 3 got: %s wanted: true", out.Value)
+	}
+
+	_, err = Compare(token.LSS, lit(token.UPX, "1px"), lit(token.UEM, "1em"), DefaultPrecision)
+	if err == nil {
+		t.Error("expected incompatible units error")
+	}
+	_, err = Compare(token.GTR, lit(token.STRING, "a"), lit(token.INT, "1"), DefaultPrecision)
+	if err == nil {
+		t.Error("expected error comparing a string")
+	}
+}
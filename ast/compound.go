@@ -0,0 +1,216 @@
+package ast
+
+import "strings"
+
+// splitCompound breaks a compound selector into its simple selectors
+// ie. "a.b#c:hover" => ["a", ".b", "#c", ":hover"]
+func splitCompound(sel string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(sel); i++ {
+		switch ch := sel[i]; ch {
+		case '.', '#', '%', '[', ':':
+			if i > start {
+				parts = append(parts, sel[start:i])
+			}
+			start = i
+			switch ch {
+			case '[':
+				for i < len(sel) && sel[i] != ']' {
+					i++
+				}
+			case ':':
+				if i+1 < len(sel) && sel[i+1] == ':' {
+					i++
+				}
+				i = skipIdent(sel, i+1)
+				// functional pseudo ie. :not(.a)
+				if i+1 < len(sel) && sel[i+1] == '(' {
+					depth := 0
+					for i++; i < len(sel); i++ {
+						if sel[i] == '(' {
+							depth++
+						} else if sel[i] == ')' {
+							depth--
+							if depth == 0 {
+								break
+							}
+						}
+					}
+				}
+			default:
+				i = skipIdent(sel, i+1)
+			}
+		}
+	}
+	if start < len(sel) {
+		parts = append(parts, sel[start:])
+	}
+	return parts
+}
+
+// isTypeSel reports whether simple is a type or universal selector
+func isTypeSel(simple string) bool {
+	return simple == "*" || isSelIdent(simple[0])
+}
+
+func isPseudoElement(simple string) bool {
+	return strings.HasPrefix(simple, "::")
+}
+
+// mergeCompound merges two compound selectors, returns false when
+// no element can match both
+func mergeCompound(a, b string) (string, bool) {
+	as, bs := splitCompound(a), splitCompound(b)
+	var typ, pseudoEl string
+	var id string
+	var simples []string
+	for _, s := range append(as, bs...) {
+		switch {
+		case isTypeSel(s):
+			switch {
+			case typ == "" || typ == "*":
+				typ = s
+			case s != "*" && s != typ:
+				return "", false
+			}
+		case isPseudoElement(s):
+			if pseudoEl != "" && pseudoEl != s {
+				return "", false
+			}
+			pseudoEl = s
+		case s[0] == '#' && id != "" && id != s:
+			return "", false
+		default:
+			if s[0] == '#' {
+				id = s
+			}
+			found := false
+			for _, x := range simples {
+				found = found || x == s
+			}
+			if !found {
+				simples = append(simples, s)
+			}
+		}
+	}
+	// the universal selector is implied when anything else is present
+	if typ == "*" && (len(simples) > 0 || pseudoEl != "") {
+		typ = ""
+	}
+	return typ + strings.Join(simples, "") + pseudoEl, true
+}
+
+// unifyComplex merges two complex selectors joined by descendant
+// combinators. Ancestors of both selectors are interleaved in both
+// orders as Sass does.
+func unifyComplex(a, b string) []string {
+	af, bf := strings.Fields(a), strings.Fields(b)
+	if len(af) == 0 || len(bf) == 0 {
+		return nil
+	}
+	last, ok := mergeCompound(af[len(af)-1], bf[len(bf)-1])
+	if !ok {
+		return nil
+	}
+	ap := strings.Join(af[:len(af)-1], " ")
+	bp := strings.Join(bf[:len(bf)-1], " ")
+	switch {
+	case len(ap) == 0 && len(bp) == 0:
+		return []string{last}
+	case len(ap) == 0:
+		return []string{bp + " " + last}
+	case len(bp) == 0, ap == bp:
+		return []string{ap + " " + last}
+	}
+	return []string{
+		ap + " " + bp + " " + last,
+		bp + " " + ap + " " + last,
+	}
+}
+
+// UnifySelector returns a selector matching elements matched by both
+// a and b, false is returned when no element can match both.
+// Only descendant combinators are supported.
+func UnifySelector(a, b string) (string, bool) {
+	var ret []string
+	for _, x := range strings.Split(a, ",") {
+		for _, y := range strings.Split(b, ",") {
+			ret = append(ret, unifyComplex(x, y)...)
+		}
+	}
+	if len(ret) == 0 {
+		return "", false
+	}
+	return strings.Join(ret, ", "), true
+}
+
+// AppendSelector appends child to parent without a descendant
+// combinator, ie. AppendSelector(".a", ".b") => ".a.b"
+func AppendSelector(parent, child string) string {
+	nodes := strings.Split(child, ",")
+	for i := range nodes {
+		nodes[i] = "&" + strings.TrimSpace(nodes[i])
+	}
+	return strings.Join(joinParent(" ", parent, nodes), ", ")
+}
+
+// SimpleSelectors splits a compound selector into its simple
+// selectors ie. ".foo.bar" => [".foo", ".bar"]
+func SimpleSelectors(compound string) []string {
+	return splitCompound(strings.TrimSpace(compound))
+}
+
+// superCompound reports whether every simple selector of super is
+// found in sub
+func superCompound(super, sub string) bool {
+	subs := splitCompound(sub)
+	for _, s := range splitCompound(super) {
+		if s == "*" {
+			continue
+		}
+		found := false
+		for _, x := range subs {
+			found = found || x == s
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// superComplex reports whether super matches every element matched
+// by sub. The last compounds must match, ancestors of super must
+// match ancestors of sub in order.
+func superComplex(super, sub string) bool {
+	sf, bf := strings.Fields(super), strings.Fields(sub)
+	if len(sf) == 0 || len(bf) == 0 || len(sf) > len(bf) {
+		return false
+	}
+	if !superCompound(sf[len(sf)-1], bf[len(bf)-1]) {
+		return false
+	}
+	i := 0
+	for _, b := range bf[:len(bf)-1] {
+		if i < len(sf)-1 && superCompound(sf[i], b) {
+			i++
+		}
+	}
+	return i == len(sf)-1
+}
+
+// IsSuperselector reports whether super matches all the elements sub
+// matches. Only descendant combinators are supported.
+func IsSuperselector(super, sub string) bool {
+	for _, b := range strings.Split(sub, ",") {
+		matched := false
+		for _, s := range strings.Split(super, ",") {
+			matched = matched || superComplex(s, b)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -77,10 +77,30 @@ func StmtCopy(in Stmt) (out Stmt) {
 	case *EachStmt:
 		stmt := &EachStmt{}
 		stmt.X = v.X
+		stmt.Value = v.Value
 		stmt.Body = StmtCopy(v.Body).(*BlockStmt)
 		stmt.List = ExprsCopy(v.List)
 		stmt.Each = v.Each
 		out = stmt
+	case *ExtendStmt:
+		stmt := *v
+		out = &stmt
+	case *DebugStmt:
+		out = &DebugStmt{
+			TokPos: v.TokPos,
+			Tok:    v.Tok,
+			X:      ExprCopy(v.X),
+		}
+	case *AtRuleStmt:
+		stmt := *v
+		if v.Body != nil {
+			stmt.Body = StmtCopy(v.Body).(*BlockStmt)
+		}
+		out = &stmt
+	case *AtRootStmt:
+		stmt := *v
+		stmt.Body = StmtCopy(v.Body).(*BlockStmt)
+		out = &stmt
 	case *EmptyStmt:
 	default:
 		log.Fatalf("unsupported stmt copy %T: % #v\n", v, v)
@@ -136,6 +156,13 @@ func ExprCopy(in Expr) (out Expr) {
 			Args:   ExprsCopy(expr.Args),
 			Fun:    ExprCopy(expr.Fun),
 		}
+	case *StringExpr:
+		out = &StringExpr{
+			Kind:   expr.Kind,
+			List:   ExprsCopy(expr.List),
+			Lquote: expr.Lquote,
+			Rquote: expr.Rquote,
+		}
 	case *KeyValueExpr:
 		kv := &KeyValueExpr{}
 		kv.Colon = expr.Colon
@@ -145,11 +172,22 @@ func ExprCopy(in Expr) (out Expr) {
 	case *ListLit:
 		lit := &ListLit{
 			Comma:    expr.Comma,
+			Paren:    expr.Paren,
+			Bracket:  expr.Bracket,
 			ValuePos: expr.Pos(),
 			EndPos:   expr.End(),
 		}
 		lit.Value = ExprsCopy(expr.Value)
 		out = lit
+	case *MapLit:
+		lit := &MapLit{
+			Lparen: expr.Lparen,
+			Rparen: expr.Rparen,
+		}
+		for _, kv := range expr.Value {
+			lit.Value = append(lit.Value, ExprCopy(kv).(*KeyValueExpr))
+		}
+		out = lit
 	default:
 		panic(fmt.Errorf("unsupported expr copy: % #v\n", expr))
 	}
@@ -214,6 +252,9 @@ func SpecCopy(in Spec) (out Spec) {
 			list[i] = StmtCopy(v.List[i])
 		}
 		spec.List = list
+		if v.Body != nil {
+			spec.Body = StmtCopy(v.Body).(*BlockStmt)
+		}
 		out = spec
 	default:
 		out = v
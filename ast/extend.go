@@ -0,0 +1,236 @@
+package ast
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/wellington/sass/token"
+)
+
+// Specificity is the CSS specificity of a complex selector in the
+// form (ids, classes, types). Attributes and pseudo-classes count
+// as classes, pseudo-elements count as types.
+type Specificity [3]int
+
+// Cmp is the spaceship of specificity, it returns -1, 0, +1 when
+// a is less than, equal to or greater than b.
+func (a Specificity) Cmp(b Specificity) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// SelSpecificity calculates the specificity of a single complex
+// selector ie. "div.a > #b"
+func SelSpecificity(sel string) Specificity {
+	var spec Specificity
+	for i := 0; i < len(sel); i++ {
+		switch ch := sel[i]; {
+		case ch == '#':
+			spec[0]++
+			i = skipIdent(sel, i+1)
+		case ch == '.', ch == '%':
+			spec[1]++
+			i = skipIdent(sel, i+1)
+		case ch == '[':
+			spec[1]++
+			for i < len(sel) && sel[i] != ']' {
+				i++
+			}
+		case ch == ':':
+			if i+1 < len(sel) && sel[i+1] == ':' {
+				// pseudo-element
+				spec[2]++
+				i++
+			} else {
+				spec[1]++
+			}
+			i = skipIdent(sel, i+1)
+		case ch == '*', ch == '&':
+		case isSelIdent(ch):
+			spec[2]++
+			i = skipIdent(sel, i)
+		}
+	}
+	return spec
+}
+
+func isSelIdent(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' ||
+		'0' <= ch && ch <= '9' || ch == '-' || ch == '_' || ch >= 0x80
+}
+
+// skipIdent returns the offset of the last ident byte starting at i
+func skipIdent(s string, i int) int {
+	for i < len(s) && isSelIdent(s[i]) {
+		i++
+	}
+	return i - 1
+}
+
+// Extension records a selector that extends another via @extend
+type Extension struct {
+	Pos token.Pos // position of @extend
+	Sel string    // resolved selector of the extending rule
+}
+
+// ExtendOrder returns the selector groups of orig followed by the
+// selectors of exts. The original selectors always retain their
+// position. Extensions are ordered by their position in the source,
+// extensions from the same @extend keep the order of the extending
+// selectors. Duplicate selectors are removed.
+func ExtendOrder(orig string, exts []Extension) string {
+	type ext struct {
+		pos token.Pos
+		sel string
+	}
+	var list []ext
+	for _, e := range exts {
+		for _, s := range SplitGroups(e.Sel) {
+			list = append(list, ext{pos: e.Pos, sel: s})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].pos < list[j].pos
+	})
+
+	seen := make(map[string]bool)
+	var ret []string
+	for _, s := range SplitGroups(orig) {
+		if len(s) == 0 || seen[s] {
+			continue
+		}
+		seen[s] = true
+		ret = append(ret, s)
+	}
+	for _, e := range list {
+		if seen[e.sel] {
+			continue
+		}
+		seen[e.sel] = true
+		ret = append(ret, e.sel)
+	}
+	return strings.Join(ret, ", ")
+}
+
+// SplitGroups splits the selector sel into its comma separated groups.
+// Commas inside parens, brackets and quotes ie. :not(.a, .b) do not
+// separate groups.
+func SplitGroups(sel string) []string {
+	var groups []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(sel); i++ {
+		ch := sel[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			groups = append(groups, strings.TrimSpace(sel[start:i]))
+			start = i + 1
+		}
+	}
+	return append(groups, strings.TrimSpace(sel[start:]))
+}
+
+// ExtendGroup replaces target in the complex selector group with each
+// of the complex selectors in extender. ok is false if target was not
+// found in group.
+//
+// .a .b extended by .c for .b => .a .c
+func ExtendGroup(group, target, extender string) (sels []string, ok bool) {
+	parts := strings.Fields(group)
+	for i, compound := range parts {
+		rest, found := removeSimple(compound, target)
+		if !found {
+			continue
+		}
+		for _, ext := range SplitGroups(extender) {
+			ext := strings.Fields(ext)
+			if len(ext) == 0 {
+				continue
+			}
+			last := len(ext) - 1
+			merged, unified := unifyCompound(rest, ext[last])
+			if !unified {
+				continue
+			}
+			var sel []string
+			sel = append(sel, parts[:i]...)
+			sel = append(sel, ext[:last]...)
+			sel = append(sel, merged)
+			sel = append(sel, parts[i+1:]...)
+			sels = append(sels, strings.Join(sel, " "))
+		}
+		return sels, true
+	}
+	return nil, false
+}
+
+// removeSimple removes target from the compound selector. found reports
+// whether target was a complete part of compound.
+func removeSimple(compound, target string) (rest string, found bool) {
+	if len(target) == 0 {
+		return compound, false
+	}
+	for i := 0; i+len(target) <= len(compound); i++ {
+		if compound[i:i+len(target)] != target {
+			continue
+		}
+		end := i + len(target)
+		// partial match ie. .ab for .a
+		if end < len(compound) && isSelIdent(compound[end]) {
+			continue
+		}
+		// type selectors may only start a compound
+		if isSelIdent(target[0]) && i > 0 {
+			continue
+		}
+		return compound[:i] + compound[end:], true
+	}
+	return compound, false
+}
+
+// unifyCompound merges rest of a compound selector with the compound
+// from an extender. Type selectors are always kept first.
+func unifyCompound(rest, ext string) (string, bool) {
+	if len(rest) == 0 {
+		return ext, true
+	}
+	rt, et := typePrefix(rest), typePrefix(ext)
+	if len(et) == 0 {
+		if rest[0] == ':' {
+			// pseudo selectors come last
+			return ext + rest, true
+		}
+		return rest + ext, true
+	}
+	if len(rt) > 0 && rt != et && rt != "*" && et != "*" {
+		// div.a can not be extended by span
+		return "", false
+	}
+	return et + rest[len(rt):] + ext[len(et):], true
+}
+
+func typePrefix(s string) string {
+	if len(s) > 0 && s[0] == '*' {
+		return "*"
+	}
+	return s[:skipIdent(s, 0)+1]
+}
@@ -0,0 +1,155 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wellington/sass/token"
+)
+
+// DefaultPrecision is the number of decimal places numbers are
+// compared at unless another is given, so that 0.1 + 0.2 == 0.3 like
+// it does in Sass
+const DefaultPrecision = 5
+
+// round returns f rounded to prec decimal places
+func round(f float64, prec int) float64 {
+	pow := math.Pow(10, float64(prec))
+	return math.Round(f*pow) / pow
+}
+
+// number is a float with the unit it was found with, unitless
+// numbers have the unit token.ILLEGAL
+type number struct {
+	f    float64
+	unit token.Token
+}
+
+// unitSuffix returns the text following the number for unit
+func unitSuffix(unit token.Token) string {
+	switch {
+	case unit == token.UPCT:
+		return "%"
+	case unit.IsCSSNum():
+		return unit.String()
+	}
+	return ""
+}
+
+func newNumber(lit *BasicLit) (number, error) {
+	var n number
+	switch {
+	case lit.Kind == token.INT, lit.Kind == token.FLOAT:
+	case lit.Kind.IsCSSNum():
+		n.unit = lit.Kind
+	default:
+		return n, fmt.Errorf("%s is not a number", lit.Value)
+	}
+	var err error
+	n.f, err = strconv.ParseFloat(
+		strings.TrimSuffix(lit.Value, unitSuffix(n.unit)), 64)
+	if err != nil {
+		return n, fmt.Errorf("%s is not a number", lit.Value)
+	}
+	return n, nil
+}
+
+func (n number) String() string {
+	return strconv.FormatFloat(n.f, 'f', -1, 64) + unitSuffix(n.unit)
+}
+
+// Lit converts n back into a BasicLit, unitless numbers are INT
+// when they fit
+func (n number) Lit(pos token.Pos) *BasicLit {
+	lit := &BasicLit{
+		Kind:     n.unit,
+		ValuePos: pos,
+		Value:    n.String(),
+	}
+	if n.unit == token.ILLEGAL {
+		lit.Kind = token.FLOAT
+		if n.f == math.Trunc(n.f) {
+			lit.Kind = token.INT
+		}
+	}
+	return lit
+}
+
+// unitOp performs op on x and y. Addition and subtraction require
+// matching units, multiplication and division by a unitless number
+// keep the unit and dividing matching units is unitless.
+func unitOp(op token.Token, x, y *BasicLit, combine bool) (*BasicLit, error) {
+	if op == token.QUO && !combine {
+		return stringOp(op, x, y, combine)
+	}
+	a, err := newNumber(x)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newNumber(y)
+	if err != nil {
+		return nil, err
+	}
+
+	mixed := a.unit != b.unit &&
+		a.unit != token.ILLEGAL && b.unit != token.ILLEGAL
+	if mixed {
+		// convertible units are handled by registered kinds
+		if fn := registeredKind(a.unit, b.unit); fn != nil &&
+			(op == token.ADD || op == token.SUB) {
+			return fn(op, x, y, combine)
+		}
+		return nil, fmt.Errorf("incompatible units %s and %s: %s %s %s",
+			unitSuffix(a.unit), unitSuffix(b.unit), x.Value, op, y.Value)
+	}
+
+	out := number{unit: a.unit}
+	if out.unit == token.ILLEGAL {
+		out.unit = b.unit
+	}
+	switch op {
+	case token.ADD:
+		out.f = a.f + b.f
+	case token.SUB:
+		out.f = a.f - b.f
+	case token.MUL:
+		if a.unit != token.ILLEGAL && b.unit != token.ILLEGAL {
+			return nil, fmt.Errorf("%s isn't a valid CSS value",
+				a.String()+"*"+b.String())
+		}
+		out.f = a.f * b.f
+	case token.QUO:
+		switch {
+		case a.unit == b.unit:
+			out.unit = token.ILLEGAL
+		case a.unit == token.ILLEGAL:
+			return nil, fmt.Errorf("%s isn't a valid CSS value",
+				a.String()+"/"+b.String())
+		}
+		out.f = a.f / b.f
+	default:
+		return nil, fmt.Errorf("unsupported operation %s", op)
+	}
+	// precision matches Sass
+	out.f = math.Round(out.f*1e10) / 1e10
+	return out.Lit(x.Pos()), nil
+}
+
+// registeredKind returns the combine func registered for both units
+func registeredKind(x, y token.Token) func(token.Token, *BasicLit, *BasicLit, bool) (*BasicLit, error) {
+	var fx, fy bool
+	var fn func(token.Token, *BasicLit, *BasicLit, bool) (*BasicLit, error)
+	for _, k := range kinds {
+		if k.unit == x {
+			fx = true
+			fn = k.combine
+		}
+		fy = fy || k.unit == y
+	}
+	if fx && fy {
+		return fn
+	}
+	return nil
+}
@@ -61,7 +61,7 @@ func (s *Scope) Insert(obj *Object, global bool) (alt *Object) {
 	// Global insanity
 	if assign, ok := obj.Decl.(*AssignStmt); ok {
 
-		if list, isList := assign.Rhs[0].(*ListLit); isList {
+		if list, isList := assign.Rhs[0].(*ListLit); isList && len(list.Value) > 0 {
 			l := len(list.Value)
 			if lit, ok := list.Value[l-1].(*BasicLit); ok {
 				if lit.Value == "!global" {
@@ -89,6 +89,27 @@ func (s *Scope) Insert(obj *Object, global bool) (alt *Object) {
 	return
 }
 
+// Set assigns obj following Sass rules. A variable declared in an
+// enclosing block is modified, otherwise obj is inserted into s
+// shadowing any global of the same name. The replaced object is
+// returned.
+func (s *Scope) Set(obj *Object, global bool) (alt *Object) {
+	if s == nil || global {
+		return s.Insert(obj, global)
+	}
+	// globals are only modified by !global or at the top level
+	for o := s; o.Outer != nil; o = o.Outer {
+		if alt = o.Objects[obj.Name]; alt != nil {
+			if o == s {
+				break
+			}
+			o.Objects[obj.Name] = obj
+			return alt
+		}
+	}
+	return s.Insert(obj, global)
+}
+
 // Debugging support
 func (s *Scope) String() string {
 	var buf bytes.Buffer
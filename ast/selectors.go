@@ -2,7 +2,7 @@ package ast
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
 	"regexp"
 	"strings"
 
@@ -10,20 +10,100 @@ import (
 )
 
 var (
-	regEql = regexp.MustCompile("\\s*(\\*?=)\\s*").ReplaceAll
-	regBkt = regexp.MustCompile("\\s*(\\[)\\s*(\\S+)\\s*(\\])").ReplaceAll
+	regEql = regexp.MustCompile("\\s*([*^$~|]?=)\\s*").ReplaceAll
+	regBkt = regexp.MustCompile("^(\\[)\\s*(.*?)\\s*(\\])$").ReplaceAll
 	nilW   = bytes.NewBuffer(nil)
 )
 
+// attrSel removes spacing inside the attribute selectors of sel ie.
+// input[ type = "text" ] => input[type="text"]. Quoted values are
+// left as is.
+func attrSel(sel string) string {
+	if !strings.Contains(sel, "[") {
+		return sel
+	}
+	var buf bytes.Buffer
+	for {
+		i := strings.IndexByte(sel, '[')
+		if i < 0 {
+			break
+		}
+		// find the closing bracket, skipping quoted values
+		var quote byte
+		j := i + 1
+		for ; j < len(sel); j++ {
+			c := sel[j]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				}
+				continue
+			}
+			if c == '"' || c == '\'' {
+				quote = c
+			} else if c == ']' {
+				break
+			}
+		}
+		if j == len(sel) {
+			break
+		}
+		attr := []byte(sel[i : j+1])
+		// only the name and operator are respaced
+		q := bytes.IndexAny(attr, `"'`)
+		if q < 0 {
+			q = len(attr)
+		}
+		attr = append(regEql(attr[:q:q], []byte("$1")), attr[q:]...)
+		buf.WriteString(sel[:i])
+		buf.Write(regBkt(attr, []byte("$1$2$3")))
+		sel = sel[j+1:]
+	}
+	buf.WriteString(sel)
+	return buf.String()
+}
+
+// collapseSpace replaces each run of whitespace in sel with a single
+// space ie. "div \n  p" => "div p". Quoted values are left as is.
+func collapseSpace(sel string) string {
+	var buf bytes.Buffer
+	var quote rune
+	var space bool
+	for _, c := range sel {
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			buf.WriteRune(c)
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r', '\f':
+			space = true
+			continue
+		case '"', '\'':
+			quote = c
+		}
+		if space && buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		space = false
+		buf.WriteRune(c)
+	}
+	return buf.String()
+}
+
 // Resolves walks selector operations removing nested Op by prepending X
-// on Y.
-func (stmt *SelStmt) Resolve(fset *token.FileSet) {
+// on Y. An error is returned when stmt has no selector, Resolved is
+// then empty.
+func (stmt *SelStmt) Resolve(fset *token.FileSet) error {
 	if stmt.Sel == nil {
-		panic(fmt.Errorf("invalid selector: % #v\n", stmt))
+		stmt.Resolved = &BasicLit{Kind: token.STRING, ValuePos: stmt.Pos()}
+		return errors.New("invalid selector")
 	}
 
 	stmt.Resolved = Selector(stmt)
-	return
+	return nil
 }
 
 func selSplit(s string) []string {
@@ -54,3 +134,9 @@ func joinParent(delim, parent string, nodes []string) []string {
 	}
 	return ret
 }
+
+// NestSelector nests child within parent resolving & in child,
+// ie. NestSelector(".a", "&:hover") => ".a:hover"
+func NestSelector(parent, child string) string {
+	return strings.Join(joinParent(" ", parent, selSplit(child)), ", ")
+}
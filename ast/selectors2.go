@@ -59,7 +59,7 @@ func mergeExpr(delim string, expr Expr, round int) []string {
 			ret = append(ret, r...)
 		}
 	case *UnaryExpr:
-		val := v.X.(*BasicLit).Value
+		val := attrSel(collapseSpace(v.X.(*BasicLit).Value))
 		if v.Op != token.NEST {
 			// Implicit backreference ie div { > e {} }
 			pieces := []string{"&", v.Op.String(), val}
@@ -69,10 +69,11 @@ func mergeExpr(delim string, expr Expr, round int) []string {
 		}
 		// X is always BasicLit, at some point this will be enforced
 	case *BasicLit:
+		val := attrSel(collapseSpace(v.Value))
 		if round == 0 {
-			ret = append(ret, "& "+v.Value)
+			ret = append(ret, "& "+val)
 		} else {
-			ret = append(ret, v.Value)
+			ret = append(ret, val)
 		}
 
 	}
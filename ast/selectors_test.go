@@ -1 +1,175 @@
 package ast
+
+import "testing"
+
+func TestSelSpecificity(t *testing.T) {
+	tests := []struct {
+		sel string
+		e   Specificity
+	}{
+		{"div", Specificity{0, 0, 1}},
+		{".a", Specificity{0, 1, 0}},
+		{"#b", Specificity{1, 0, 0}},
+		{"div.a > #b", Specificity{1, 1, 1}},
+		{"a[href]:hover", Specificity{0, 2, 1}},
+		{"p::before", Specificity{0, 0, 2}},
+		{"*", Specificity{0, 0, 0}},
+	}
+	for _, test := range tests {
+		if got := SelSpecificity(test.sel); got != test.e {
+			t.Errorf("%s got: %v wanted: %v", test.sel, got, test.e)
+		}
+	}
+
+	if c := SelSpecificity("#a").Cmp(SelSpecificity(".a.b.c")); c != 1 {
+		t.Errorf("got: %d wanted: 1", c)
+	}
+}
+
+func TestExtendOrder(t *testing.T) {
+	exts := []Extension{
+		{Pos: 20, Sel: ".c"},
+		{Pos: 10, Sel: "#id, div"},
+		{Pos: 30, Sel: ".a"},
+	}
+	e := ".a, #id, div, .c"
+	if got := ExtendOrder(".a", exts); got != e {
+		t.Errorf("got: %q wanted: %q", got, e)
+	}
+}
+
+func TestSplitGroups(t *testing.T) {
+	tests := []struct {
+		sel string
+		e   []string
+	}{
+		{".a, .b", []string{".a", ".b"}},
+		{".x:not(.a, .b), .y", []string{".x:not(.a, .b)", ".y"}},
+		{`a[title="a, b"],p`, []string{`a[title="a, b"]`, "p"}},
+	}
+	for _, test := range tests {
+		got := SplitGroups(test.sel)
+		if len(got) != len(test.e) {
+			t.Fatalf("%s got: %q wanted: %q", test.sel, got, test.e)
+		}
+		for i := range got {
+			if got[i] != test.e[i] {
+				t.Errorf("%s got: %q wanted: %q", test.sel, got, test.e)
+			}
+		}
+	}
+}
+
+func TestNestSelector(t *testing.T) {
+	tests := []struct {
+		parent, child string
+		e             string
+	}{
+		{".a", ".b", ".a .b"},
+		{".a", "&:hover", ".a:hover"},
+		{".a, .b", "& + &", ".a + .a, .b + .b"},
+		{".a", ".b, p &", ".a .b, p .a"},
+	}
+	for _, test := range tests {
+		if got := NestSelector(test.parent, test.child); got != test.e {
+			t.Errorf("%s %s got: %q wanted: %q", test.parent, test.child, got, test.e)
+		}
+	}
+}
+
+func TestAppendSelector(t *testing.T) {
+	tests := []struct {
+		parent, child string
+		e             string
+	}{
+		{".a", ".b", ".a.b"},
+		{".a", "__el", ".a__el"},
+		{".a, .b", ":hover", ".a:hover, .b:hover"},
+	}
+	for _, test := range tests {
+		if got := AppendSelector(test.parent, test.child); got != test.e {
+			t.Errorf("%s %s got: %q wanted: %q", test.parent, test.child, got, test.e)
+		}
+	}
+}
+
+func TestUnifySelector(t *testing.T) {
+	tests := []struct {
+		a, b string
+		e    string
+		ok   bool
+	}{
+		{".a", ".b", ".a.b", true},
+		{"a.x", ".y#z", "a.x.y#z", true},
+		{".a", ".a:hover", ".a:hover", true},
+		{"*", ".a", ".a", true},
+		{".a::before", "p", "p.a::before", true},
+		{".a .b", ".c .d", ".a .c .b.d, .c .a .b.d", true},
+		{".a, .b", "p", "p.a, p.b", true},
+		{"a", "b", "", false},
+		{"#a", "#b", "", false},
+		{"::before", "::after", "", false},
+	}
+	for _, test := range tests {
+		got, ok := UnifySelector(test.a, test.b)
+		if ok != test.ok {
+			t.Errorf("%s %s got: %v wanted: %v", test.a, test.b, ok, test.ok)
+		}
+		if got != test.e {
+			t.Errorf("%s %s got: %q wanted: %q", test.a, test.b, got, test.e)
+		}
+	}
+}
+
+func TestSimpleSelectors(t *testing.T) {
+	tests := []struct {
+		sel string
+		e   []string
+	}{
+		{".foo.bar", []string{".foo", ".bar"}},
+		{"a#b[c=d]:hover::before", []string{"a", "#b", "[c=d]", ":hover", "::before"}},
+		{"p:not(.a.b)", []string{"p", ":not(.a.b)"}},
+	}
+	for _, test := range tests {
+		got := SimpleSelectors(test.sel)
+		if len(got) != len(test.e) {
+			t.Errorf("%s got: %q wanted: %q", test.sel, got, test.e)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.e[i] {
+				t.Errorf("%s got: %q wanted: %q", test.sel, got, test.e)
+			}
+		}
+	}
+}
+
+func TestIsSuperselector(t *testing.T) {
+	tests := []struct {
+		super, sub string
+		e          bool
+	}{
+		{".a", ".a.b", true},
+		{".a.b", ".a", false},
+		{".a", "div .a", true},
+		{"div .a", ".a", false},
+		{".x .a", ".x .y .a.b", true},
+		{".a, .b", ".b", true},
+		{".a", ".a, .b", false},
+	}
+	for _, test := range tests {
+		if got := IsSuperselector(test.super, test.sub); got != test.e {
+			t.Errorf("%s %s got: %v wanted: %v", test.super, test.sub, got, test.e)
+		}
+	}
+}
+
+func TestSelStmtResolve(t *testing.T) {
+	stmt := &SelStmt{NamePos: 10}
+	if err := stmt.Resolve(nil); err == nil {
+		t.Fatal("expected invalid selector error")
+	}
+	if stmt.Resolved == nil || stmt.Resolved.Pos() != 10 {
+		t.Errorf("got: % #v wanted empty selector at 10", stmt.Resolved)
+	}
+}
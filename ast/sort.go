@@ -14,14 +14,15 @@ func (s Stmts) lookup(pos int) int {
 	i := 0
 	switch s[pos].(type) {
 	case *DeclStmt, *IncludeStmt, *EmptyStmt,
-		*AssignStmt, *BadStmt, *EachStmt, *IfStmt:
+		*AssignStmt, *BadStmt, *EachStmt, *IfStmt, *ExtendStmt,
+		*DebugStmt:
 	case *ReturnStmt:
 	case *CommStmt:
 	case *BlockStmt:
 		// This is an error situation, but better errors are
 		// reported if it gets sorted
 		i = 1000
-	case *SelStmt, *MediaStmt:
+	case *SelStmt, *MediaStmt, *AtRuleStmt, *AtRootStmt:
 		// log.Printf("pushing to end % #v\n", v)
 		//Print(token.NewFileSet(), v)
 		i = 1
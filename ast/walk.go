@@ -112,6 +112,11 @@ func Walk(v Visitor, node Node) {
 	case *ListLit:
 		walkExprList(v, n.Value)
 
+	case *MapLit:
+		for _, kv := range n.Value {
+			Walk(v, kv)
+		}
+
 	case *CompositeLit:
 		if n.Type != nil {
 			Walk(v, n.Type)
@@ -160,6 +165,9 @@ func Walk(v Visitor, node Node) {
 
 	case *StringExpr:
 
+	case *Interp:
+		walkExprList(v, n.X)
+
 	case *UnaryExpr:
 		Walk(v, n.X)
 
@@ -247,6 +255,16 @@ func Walk(v Visitor, node Node) {
 
 	case *IfDecl:
 		Walk(v, n.IfStmt)
+	case *MediaDecl:
+		Walk(v, n.MediaStmt)
+	case *CommDecl:
+		Walk(v, n.CommStmt)
+	case *DebugDecl:
+		Walk(v, n.DebugStmt)
+	case *AtRuleDecl:
+		Walk(v, n.AtRuleStmt)
+	case *AtRootDecl:
+		Walk(v, n.AtRootStmt)
 	case *IfStmt:
 		if n.Init != nil {
 			Walk(v, n.Init)
@@ -317,6 +335,9 @@ func Walk(v Visitor, node Node) {
 			Walk(v, n.Name)
 		}
 		Walk(v, n.Path)
+		if n.Media != nil {
+			Walk(v, n.Media)
+		}
 		if n.Comment != nil {
 			Walk(v, n.Comment)
 		}
@@ -399,6 +420,23 @@ func Walk(v Visitor, node Node) {
 	case *SelStmt:
 		Walk(v, n.Body)
 
+	case *ExtendStmt:
+		// nothing to do
+
+	case *DebugStmt:
+		Walk(v, n.X)
+
+	case *AtRuleStmt:
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+
+	case *AtRootStmt:
+		if n.Query != nil {
+			Walk(v, n.Query)
+		}
+		Walk(v, n.Body)
+
 	default:
 		log.Fatal(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
 	}
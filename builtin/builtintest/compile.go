@@ -0,0 +1,53 @@
+// Package builtintest provides utilities for testing Sass builtin
+// functions without a full stylesheet.
+package builtintest
+
+import (
+	"fmt"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/parser"
+	"github.com/wellington/sass/token"
+)
+
+// CompileString resolves a single Sass expression ie. lighten(#800, 10%)
+// using the registered builtins and returns the resulting literal.
+func CompileString(expr string) (*ast.BasicLit, error) {
+	x, err := CompileExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	lit, ok := x.(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("%s did not resolve to a literal: %T", expr, x)
+	}
+	return lit, nil
+}
+
+// CompileExpr resolves a single Sass expression like CompileString,
+// but allows results that are lists or maps.
+func CompileExpr(expr string) (ast.Expr, error) {
+	fset := token.NewFileSet()
+	src := fmt.Sprintf("a { b: %s; }", expr)
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec *ast.RuleSpec
+	ast.Inspect(f, func(n ast.Node) bool {
+		if rs, ok := n.(*ast.RuleSpec); ok {
+			spec = rs
+		}
+		return spec == nil
+	})
+	if spec == nil || len(spec.Values) != 1 {
+		return nil, fmt.Errorf("%s is not a single expression", expr)
+	}
+
+	x := spec.Values[0]
+	if call, ok := x.(*ast.CallExpr); ok {
+		x = call.Resolved
+	}
+	return x, nil
+}
@@ -0,0 +1,18 @@
+package builtintest
+
+import "testing"
+
+func TestCompileString(t *testing.T) {
+	lit, err := CompileString("lighten(#800, 10%)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := "#bb0000"; lit.Value != e {
+		t.Errorf("got: %s wanted: %s", lit.Value, e)
+	}
+
+	_, err = CompileString("math.clamp(0px, 5em, 3px)")
+	if err == nil {
+		t.Error("expected error for incompatible units")
+	}
+}
@@ -0,0 +1,165 @@
+package colors
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/calc"
+)
+
+const channelParams = "$color, $red, $green, $blue, $hue, $saturation, $lightness, $alpha"
+
+func init() {
+	builtin.Reg("adjust-color("+channelParams+")", adjustColor)
+	builtin.Reg("scale-color("+channelParams+")", scaleColor)
+	builtin.Reg("change-color("+channelParams+")", changeColor)
+}
+
+var channelNames = []string{
+	"$red", "$green", "$blue", "$hue", "$saturation", "$lightness", "$alpha",
+}
+
+// channels is a color split into every channel Sass can adjust,
+// rgb [0, 255], hue [0, 360), saturation and lightness [0, 100]
+// and alpha [0, 1]
+type channels [7]float64
+
+const (
+	chRed = iota
+	chGreen
+	chBlue
+	chHue
+	chSaturation
+	chLightness
+	chAlpha
+)
+
+var channelMax = channels{255, 255, 255, 360, 100, 100, 1}
+
+var errMixedChannels = errors.New("Cannot specify HSL and RGB values for a color at the same time")
+
+func toChannels(c color.RGBA) channels {
+	h, s, l := rgbToHSL(c)
+	return channels{
+		float64(c.R), float64(c.G), float64(c.B),
+		h, s * 100, l * 100,
+		float64(c.A) / 100,
+	}
+}
+
+// parseChannelArgs reads the color and the channels passed by
+// keyword, set reports which channels were passed
+func parseChannelArgs(args []ast.Expr) (c color.RGBA, amts channels, set [7]bool, err error) {
+	lit, err := calc.Resolve(args[0], true, ast.DefaultPrecision)
+	if err != nil {
+		return
+	}
+	c, err = ast.ColorFromHexString(lit.Value)
+	if err != nil {
+		err = fmt.Errorf("$color: %s is not a color", lit.Value)
+		return
+	}
+	var rgb, hsl bool
+	for i, x := range args[1:] {
+		if x == nil {
+			continue
+		}
+		lit, err = calc.Resolve(x, true, ast.DefaultPrecision)
+		if err != nil {
+			return
+		}
+		s := strings.TrimSuffix(strings.TrimSuffix(lit.Value, "%"), "deg")
+		amts[i], err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			err = fmt.Errorf("%s: %s is not a number", channelNames[i], lit.Value)
+			return
+		}
+		set[i] = true
+		rgb = rgb || i <= chBlue
+		hsl = hsl || (i >= chHue && i <= chLightness)
+	}
+	if rgb && hsl {
+		err = errMixedChannels
+	}
+	return
+}
+
+// fromChannels builds the color from ch, hsl channels are used when
+// any were modified
+func fromChannels(ch channels, hsl bool, call *ast.CallExpr) *ast.BasicLit {
+	for i := range ch {
+		ch[i] = math.Max(0, math.Min(channelMax[i], ch[i]))
+	}
+	var c color.RGBA
+	if hsl {
+		c.R, c.G, c.B = hslToRGB(ch[chHue], ch[chSaturation]/100,
+			ch[chLightness]/100)
+	} else {
+		c.R = uint8(round(ch[chRed], 0))
+		c.G = uint8(round(ch[chGreen], 0))
+		c.B = uint8(round(ch[chBlue], 0))
+	}
+	c.A = uint8(round(ch[chAlpha]*100, 0))
+	if c.A < 100 {
+		return colorOutput(c, &ast.CallExpr{
+			Fun: &ast.Ident{Name: "rgba"},
+		})
+	}
+	return colorOutput(c, call.Args[0])
+}
+
+// modifyColor applies fn to every channel passed to the call
+func modifyColor(call *ast.CallExpr, args []ast.Expr, fn func(i int, v, amt float64) float64) (ast.Expr, error) {
+	c, amts, set, err := parseChannelArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	ch := toChannels(c)
+	hsl := false
+	for i := range ch {
+		if set[i] {
+			ch[i] = fn(i, ch[i], amts[i])
+			hsl = hsl || (i >= chHue && i <= chLightness)
+		}
+	}
+	if hsl {
+		// hue wraps around the color wheel
+		ch[chHue] = math.Mod(ch[chHue]+360, 360)
+	}
+	return fromChannels(ch, hsl, call), nil
+}
+
+// adjustColor adds the amounts passed to each channel
+func adjustColor(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	return modifyColor(call, args, func(i int, v, amt float64) float64 {
+		return v + amt
+	})
+}
+
+// scaleColor moves each channel towards its maximum or minimum
+// by the percentage passed
+func scaleColor(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	if args[1+chHue] != nil {
+		return nil, errors.New("$hue: scale-color() does not accept $hue")
+	}
+	return modifyColor(call, args, func(i int, v, amt float64) float64 {
+		amt /= 100
+		if amt > 0 {
+			return v + (channelMax[i]-v)*amt
+		}
+		return v + v*amt
+	})
+}
+
+// changeColor replaces each channel with the value passed
+func changeColor(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	return modifyColor(call, args, func(i int, v, amt float64) float64 {
+		return amt
+	})
+}
@@ -0,0 +1,118 @@
+package colors
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+)
+
+func init() {
+	builtin.Register("lighten($color, $amount)", lighten)
+	builtin.Register("darken($color, $amount)", darken)
+}
+
+// parsePercent reads an amount ie. 10% or 10 as a fraction
+func parsePercent(lit *ast.BasicLit) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(lit.Value, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("$amount: %s is not a number", lit.Value)
+	}
+	if f < 0 || f > 100 {
+		return 0, fmt.Errorf("$amount: Amount %s must be between 0%% and 100%%",
+			lit.Value)
+	}
+	return f / 100, nil
+}
+
+// rgbToHSL converts c to hue [0, 360), saturation and lightness [0, 1]
+func rgbToHSL(c color.RGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l = (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+	d := max - min
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	} else {
+		s = d / (max + min)
+	}
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	case b:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// hslToRGB converts hsl back to c, alpha is left untouched
+func hslToRGB(h, s, l float64) (r, g, b uint8) {
+	hue := func(p, q, t float64) float64 {
+		switch {
+		case t < 0:
+			t++
+		case t > 1:
+			t--
+		}
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 1.0/2:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	h /= 360
+	// channels exactly halfway ie. 127.5 lose precision in the
+	// conversion, nudge them so they always round up
+	channel := func(t float64) uint8 {
+		return uint8(round(hue(p, q, t)*255+1e-9, 0))
+	}
+	return channel(h + 1.0/3), channel(h), channel(h - 1.0/3)
+}
+
+// adjustLightness adds amount to the lightness of the color in args[0]
+func adjustLightness(call *ast.CallExpr, args []*ast.BasicLit, sign float64) (*ast.BasicLit, error) {
+	c, err := ast.ColorFromHexString(args[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("$color: %s is not a color", args[0].Value)
+	}
+	amt, err := parsePercent(args[1])
+	if err != nil {
+		return nil, err
+	}
+	h, s, l := rgbToHSL(c)
+	l = math.Max(0, math.Min(1, l+sign*amt))
+	c.R, c.G, c.B = hslToRGB(h, s, l)
+	return colorOutput(c, call.Args[0]), nil
+}
+
+func lighten(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	return adjustLightness(call, args, 1)
+}
+
+func darken(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	return adjustLightness(call, args, -1)
+}
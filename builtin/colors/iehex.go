@@ -0,0 +1,29 @@
+package colors
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Register("ie-hex-str($color)", ieHexStr)
+}
+
+// ieHexStr formats a color as #AARRGGBB for IE filters
+func ieHexStr(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	c, err := ast.ColorFromHexString(args[0].Value)
+	if err != nil {
+		return nil, fmt.Errorf("$color: %s is not a color", args[0].Value)
+	}
+	a := uint8(round(float64(c.A)/100*255, 0))
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		ValuePos: call.Pos(),
+		Value: strings.ToUpper(fmt.Sprintf("#%02x%02x%02x%02x",
+			a, c.R, c.G, c.B)),
+	}, nil
+}
@@ -254,7 +254,8 @@ func colorOutput(c color.RGBA, outTyp ast.Expr) *ast.BasicLit {
 		default:
 			log.Fatal("unsupported ident", ctx.Fun.(*ast.Ident).Name)
 		}
-	case *ast.BasicLit:
+	default:
+		// variables and literals output as hex
 		lit = ast.BasicLitFromColor(c)
 	}
 	if attemptLookup {
@@ -0,0 +1,101 @@
+package builtin
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/scanner"
+)
+
+// Env holds state shared by builtins for the duration of a single
+// compilation. Builtins like unique-id() use it to return a different
+// result on each call.
+type Env struct {
+	// Rand is the source of randomness available to builtins
+	Rand *rand.Rand
+	// Vars are defined in the outermost scope before parsing,
+	// !default declarations of them have no effect
+	Vars map[string]*ast.AssignStmt
+	// Imports caches imported files, when not nil each file is
+	// read and scanned once
+	Imports *scanner.Cache
+	// Precision is the number of decimal places numbers are
+	// compared at
+	Precision int
+	ids       int64
+	// content blocks of the mixins being included, the last
+	// is the innermost mixin
+	contents []*ast.BlockStmt
+}
+
+// NewEnv returns an Env seeded by the current time
+func NewEnv() *Env {
+	return NewEnvSeed(time.Now().UnixNano())
+}
+
+// NewEnvSeed returns an Env seeded by seed, builtins return the same
+// results for the same seed.
+func NewEnvSeed(seed int64) *Env {
+	env := &Env{
+		Rand:      rand.New(rand.NewSource(seed)),
+		Precision: ast.DefaultPrecision,
+	}
+	// start counting at a random offset, so ids are unlikely to
+	// match ids from other compilations
+	env.ids = env.Rand.Int63n(1 << 32)
+	return env
+}
+
+// UniqueID returns an identifier that is unique within the Env.
+// Identifiers start with a letter, so they are valid CSS identifiers.
+func (env *Env) UniqueID() string {
+	env.ids++
+	return "u" + strconv.FormatInt(env.ids, 36)
+}
+
+// EnterMixin records the inclusion of a mixin passed the content
+// block content, content is nil when no block was passed.
+func (env *Env) EnterMixin(content *ast.BlockStmt) {
+	env.contents = append(env.contents, content)
+}
+
+// ExitMixin ends the innermost mixin
+func (env *Env) ExitMixin() {
+	env.contents = env.contents[:len(env.contents)-1]
+}
+
+// Content returns the content block passed to the innermost mixin,
+// false is returned outside of a mixin.
+func (env *Env) Content() (*ast.BlockStmt, bool) {
+	if len(env.contents) == 0 {
+		return nil, false
+	}
+	return env.contents[len(env.contents)-1], true
+}
+
+// EnvFunc describes a Sass function requiring the compilation Env
+type EnvFunc func(env *Env, expr *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error)
+
+var envReg func(s string, ch EnvFunc)
+
+var envs = map[string]EnvFunc{}
+
+// BindRegisterEnv allows the binding of EnvFunc
+func BindRegisterEnv(fn func(s string, ch EnvFunc)) {
+	envReg = fn
+	for k, v := range envs {
+		envReg(k, v)
+		delete(envs, k)
+	}
+}
+
+// RegisterEnv registers an EnvFunc for use by parser
+func RegisterEnv(s string, ch EnvFunc) {
+	if envReg != nil {
+		envReg(s, ch)
+		return
+	}
+	envs[s] = ch
+}
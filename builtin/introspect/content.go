@@ -0,0 +1,28 @@
+package introspect
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.RegisterEnv("content-exists()", contentExists)
+}
+
+// contentExists reports whether the mixin being included was passed
+// a content block
+func contentExists(env *builtin.Env, call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	body, ok := env.Content()
+	if !ok {
+		return nil, errors.New("content-exists() may only be called within a mixin")
+	}
+	return &ast.BasicLit{
+		Kind:     token.BOOL,
+		Value:    strconv.FormatBool(body != nil),
+		ValuePos: call.Pos(),
+	}, nil
+}
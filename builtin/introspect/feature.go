@@ -0,0 +1,33 @@
+package introspect
+
+import (
+	"strconv"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/strops"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Register("feature-exists($feature)", featureExists)
+}
+
+// features supported by the compiler
+var features = map[string]bool{
+	"global-variable-shadowing":   true,
+	"extend-selector-pseudoclass": true,
+	"units-level-3":               true,
+	"at-error":                    true,
+}
+
+// featureExists reports whether $feature is supported, unknown
+// features are false
+func featureExists(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	ok := features[strops.Unquote(args[0].Value)]
+	return &ast.BasicLit{
+		Kind:     token.BOOL,
+		Value:    strconv.FormatBool(ok),
+		ValuePos: call.Pos(),
+	}, nil
+}
@@ -0,0 +1,26 @@
+package introspect
+
+import (
+	"fmt"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+)
+
+func init() {
+	builtin.Reg("keywords($args)", keywords)
+}
+
+// keywords returns a map of the keyword arguments passed to a variadic
+// argument ie. $args... Keys do not include the leading $.
+func keywords(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	list, ok := args[0].(*ast.ListLit)
+	if !ok {
+		return nil, fmt.Errorf("$args: %s is not a variable argument list", args[0])
+	}
+	return &ast.MapLit{
+		Lparen: call.Pos(),
+		Value:  list.Keywords,
+		Rparen: call.End(),
+	}, nil
+}
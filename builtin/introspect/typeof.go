@@ -3,14 +3,16 @@ package introspect
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/wellington/sass/ast"
 	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/strops"
 	"github.com/wellington/sass/token"
 )
 
 func init() {
-	builtin.Register("inspect($value)", inspect)
+	builtin.Reg("inspect($value)", inspect)
 	builtin.Register("unit($number)", unit)
 	builtin.Reg("type-of($value)", typeOf)
 }
@@ -39,11 +41,83 @@ func unit(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
 	return lit, nil
 }
 
-func inspect(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+// inspect returns an unquoted string of the value as it would be
+// written in Sass, quotes and separators are preserved
+func inspect(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
 	if len(args) != 1 {
 		return nil, fmt.Errorf("wrong number of arguments (%d for 1) for 'inspect'", len(args))
 	}
-	return args[0], nil
+	s, err := inspectExpr(args[0], "")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		Value:    s,
+		ValuePos: call.Pos(),
+	}, nil
+}
+
+// inspectExpr serializes x found in a list separated by sep. Nested
+// lists are wrapped in parens when the separator is ambiguous.
+func inspectExpr(x ast.Expr, sep string) (string, error) {
+	switch v := x.(type) {
+	case *ast.BasicLit:
+		switch v.Kind {
+		case token.QSTRING, token.QSSTRING:
+			return strops.Wrap(v.Value), nil
+		}
+		return v.Value, nil
+	case *ast.StringExpr:
+		var s string
+		for _, x := range v.List {
+			lit, ok := x.(*ast.BasicLit)
+			if !ok {
+				return "", fmt.Errorf("unsupported string part %T", x)
+			}
+			s += lit.Value
+		}
+		return strops.Wrap(s), nil
+	case *ast.ListLit:
+		if len(v.Value) == 0 {
+			return "()", nil
+		}
+		delim := " "
+		if v.Comma {
+			delim = ", "
+		}
+		ss := make([]string, len(v.Value))
+		for i := range v.Value {
+			var err error
+			ss[i], err = inspectExpr(v.Value[i], delim)
+			if err != nil {
+				return "", err
+			}
+		}
+		s := strings.Join(ss, delim)
+		switch {
+		case v.Bracket:
+			s = "[" + s + "]"
+		case sep != "" && len(v.Value) > 1 && (v.Comma || sep == " "):
+			s = "(" + s + ")"
+		}
+		return s, nil
+	case *ast.MapLit:
+		ss := make([]string, len(v.Value))
+		for i, kv := range v.Value {
+			k, err := inspectExpr(kv.Key, ", ")
+			if err != nil {
+				return "", err
+			}
+			val, err := inspectExpr(kv.Value, ", ")
+			if err != nil {
+				return "", err
+			}
+			ss[i] = k + ": " + val
+		}
+		return "(" + strings.Join(ss, ", ") + ")", nil
+	}
+	return "", fmt.Errorf("unsupported value for inspect: %T", x)
 }
 
 func typeOf(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
@@ -61,6 +135,10 @@ func typeOf(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
 			lit.Value = "number"
 		case token.STRING, token.QSSTRING, token.QSTRING:
 			lit.Value = "string"
+		case token.BOOL:
+			lit.Value = "bool"
+		case token.NULL:
+			lit.Value = "null"
 		default:
 			lit.Kind = token.ILLEGAL
 		}
@@ -33,3 +33,41 @@ func TestTypeOf(t *testing.T) {
 		t.Errorf("got: %s wanted: %s", lit.Value, e)
 	}
 }
+
+func TestInspect(t *testing.T) {
+	lit := func(kind token.Token, s string) *ast.BasicLit {
+		return &ast.BasicLit{Kind: kind, Value: s}
+	}
+	space := &ast.ListLit{Value: []ast.Expr{
+		lit(token.INT, "1"), lit(token.INT, "2"),
+	}}
+	comma := &ast.ListLit{Comma: true, Value: []ast.Expr{
+		lit(token.INT, "1"), lit(token.INT, "2"),
+	}}
+	tests := []struct {
+		in ast.Expr
+		e  string
+	}{
+		{lit(token.QSTRING, "a"), `"a"`},
+		{lit(token.STRING, "a"), "a"},
+		{lit(token.STRING, "null"), "null"},
+		{&ast.ListLit{Comma: true, Value: []ast.Expr{space, lit(token.INT, "3")}}, "1 2, 3"},
+		{&ast.ListLit{Comma: true, Value: []ast.Expr{comma, lit(token.INT, "3")}}, "(1, 2), 3"},
+		{&ast.ListLit{Value: []ast.Expr{space, lit(token.INT, "3")}}, "(1 2) 3"},
+		{&ast.ListLit{Bracket: true, Value: space.Value}, "[1 2]"},
+		{&ast.ListLit{}, "()"},
+		{&ast.MapLit{Value: []*ast.KeyValueExpr{
+			{Key: lit(token.STRING, "a"), Value: comma},
+			{Key: lit(token.QSTRING, "b"), Value: space},
+		}}, `(a: (1, 2), "b": 1 2)`},
+	}
+	for _, tt := range tests {
+		x, err := inspect(&ast.CallExpr{Fun: &ast.Ident{}}, tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := x.(*ast.BasicLit).Value; s != tt.e {
+			t.Errorf("got: %s wanted: %s", s, tt.e)
+		}
+	}
+}
@@ -21,13 +21,7 @@ func nth(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
 		return nil, err
 	}
 
-	list, ok := in.(*ast.ListLit)
-	if !ok {
-		list = &ast.ListLit{
-			Value: []ast.Expr{in},
-		}
-	}
-
+	list := toList(in)
 	if pos > len(list.Value) {
 		return nil, fmt.Errorf("index out of bounds for `nth($list, $n)` at %d", in.Pos())
 	}
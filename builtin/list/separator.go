@@ -0,0 +1,85 @@
+package list
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Reg("list-separator($list)", listSeparator)
+	builtin.Reg("set-nth($list, $n, $value)", setNth)
+}
+
+// toList wraps single values in a space separated list. Maps are
+// comma separated lists of key value pairs.
+func toList(x ast.Expr) *ast.ListLit {
+	switch v := x.(type) {
+	case *ast.ListLit:
+		return v
+	case *ast.MapLit:
+		list := &ast.ListLit{
+			ValuePos: v.Pos(),
+			Comma:    true,
+			EndPos:   v.End(),
+		}
+		for _, kv := range v.Value {
+			list.Value = append(list.Value, &ast.ListLit{
+				ValuePos: kv.Pos(),
+				Value:    []ast.Expr{kv.Key, kv.Value},
+				EndPos:   kv.End(),
+			})
+		}
+		return list
+	}
+	return &ast.ListLit{
+		ValuePos: x.Pos(),
+		Value:    []ast.Expr{x},
+		EndPos:   x.End(),
+	}
+}
+
+// listSeparator returns comma or space depending on how $list
+// is delimited
+func listSeparator(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	list := toList(args[0])
+	lit := &ast.BasicLit{
+		Kind:     token.STRING,
+		ValuePos: args[0].Pos(),
+		Value:    "space",
+	}
+	if list.Comma {
+		lit.Value = "comma"
+	}
+	return lit, nil
+}
+
+// setNth returns a copy of $list with the item at $n replaced by
+// $value. Negative $n counts from the end of the list.
+func setNth(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	list := toList(args[0])
+	s, ok := args[1].(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("$n: %T is not a number", args[1])
+	}
+	n, err := strconv.Atoi(s.Value)
+	if err != nil {
+		return nil, fmt.Errorf("$n: %s is not an integer", s.Value)
+	}
+	l := len(list.Value)
+	if n == 0 || n > l || n < -l {
+		return nil, fmt.Errorf("$n: Invalid index %d for a list with %d elements", n, l)
+	}
+	if n < 0 {
+		n = l + n + 1
+	}
+
+	cpy := *list
+	cpy.Value = make([]ast.Expr, l)
+	copy(cpy.Value, list.Value)
+	cpy.Value[n-1] = args[2]
+	return &cpy, nil
+}
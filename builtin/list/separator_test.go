@@ -0,0 +1,34 @@
+package list
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/token"
+)
+
+func TestSetNth_negative(t *testing.T) {
+	lit := func(s string) *ast.BasicLit {
+		return &ast.BasicLit{Kind: token.STRING, Value: s}
+	}
+	list := &ast.ListLit{
+		Value: []ast.Expr{lit("a"), lit("b"), lit("c")},
+	}
+	n := &ast.BasicLit{Kind: token.INT, Value: "-1"}
+	x, err := setNth(nil, list, n, lit("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := x.(*ast.ListLit)
+	if e := "x"; out.Value[2].(*ast.BasicLit).Value != e {
+		t.Errorf("got: %s wanted: %s", out.Value[2], e)
+	}
+	if e := "c"; list.Value[2].(*ast.BasicLit).Value != e {
+		t.Errorf("input list was modified")
+	}
+
+	n.Value = "-4"
+	if _, err := setNth(nil, list, n, lit("x")); err == nil {
+		t.Error("expected error for out of range index")
+	}
+}
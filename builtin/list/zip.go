@@ -0,0 +1,42 @@
+package list
+
+import (
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+)
+
+func init() {
+	builtin.Reg("zip($lists...)", zip)
+}
+
+// zip combines lists element-wise into a comma separated list of
+// space separated lists. The result is as long as the shortest list.
+func zip(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	lists := make([][]ast.Expr, len(args))
+	min := -1
+	for i, x := range args {
+		if list, ok := x.(*ast.ListLit); ok {
+			lists[i] = list.Value
+		} else {
+			lists[i] = []ast.Expr{x}
+		}
+		if min < 0 || len(lists[i]) < min {
+			min = len(lists[i])
+		}
+	}
+
+	out := &ast.ListLit{
+		ValuePos: call.Pos(),
+		Comma:    true,
+		EndPos:   call.End(),
+	}
+	for i := 0; i < min; i++ {
+		sub := &ast.ListLit{ValuePos: lists[0][i].Pos()}
+		for j := range lists {
+			sub.Value = append(sub.Value, lists[j][i])
+		}
+		sub.EndPos = sub.Value[len(sub.Value)-1].End()
+		out.Value = append(out.Value, sub)
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package maps
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Reg("map-get($map, $key)", mapGet)
+	builtin.Reg("map-has-key($map, $key)", mapHasKey)
+}
+
+// toMap returns x as a map, the empty list () is an empty map. name
+// is the argument reported when x is not a map.
+func toMap(name string, x ast.Expr) (*ast.MapLit, error) {
+	switch v := x.(type) {
+	case *ast.MapLit:
+		return v, nil
+	case *ast.ListLit:
+		if len(v.Value) == 0 {
+			return &ast.MapLit{Lparen: v.Pos(), Rparen: v.End()}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %s is not a map", name, describe(x))
+}
+
+// describe prints x for use in error messages
+func describe(x ast.Expr) string {
+	switch v := x.(type) {
+	case *ast.BasicLit:
+		return v.Value
+	case *ast.ListLit:
+		return "a list"
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+// lookup finds the value stored at key
+func lookup(m *ast.MapLit, key ast.Expr) (ast.Expr, bool) {
+	k, ok := key.(*ast.BasicLit)
+	if !ok {
+		return nil, false
+	}
+	for _, kv := range m.Value {
+		lit, ok := kv.Key.(*ast.BasicLit)
+		if ok && lit.Value == k.Value {
+			return kv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// mapGet returns the value of $key in $map or null if $key is missing
+func mapGet(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	m, err := toMap("$map", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if val, ok := lookup(m, args[1]); ok {
+		return val, nil
+	}
+	return &ast.BasicLit{
+		Kind:     token.NULL,
+		Value:    "null",
+		ValuePos: call.Pos(),
+	}, nil
+}
+
+// mapHasKey reports whether $map contains $key
+func mapHasKey(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	m, err := toMap("$map", args[0])
+	if err != nil {
+		return nil, err
+	}
+	_, ok := lookup(m, args[1])
+	return &ast.BasicLit{
+		Kind:     token.BOOL,
+		Value:    strconv.FormatBool(ok),
+		ValuePos: call.Pos(),
+	}, nil
+}
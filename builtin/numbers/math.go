@@ -0,0 +1,170 @@
+package numbers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Register("math.clamp($min, $number, $max)", clamp)
+	builtin.Register("math.hypot($numbers...)", hypot)
+	builtin.Register("abs($number)", abs)
+	builtin.RegisterEnv("min($numbers...)", minimum)
+	builtin.RegisterEnv("max($numbers...)", maximum)
+}
+
+// number is a float with the unit it was found with
+type number struct {
+	lit  *ast.BasicLit
+	f    float64
+	unit string
+}
+
+func parseNumber(lit *ast.BasicLit) (number, error) {
+	n := number{lit: lit}
+	switch {
+	case lit.Kind == token.INT, lit.Kind == token.FLOAT:
+	case lit.Kind == token.UPCT:
+		n.unit = "%"
+	case lit.Kind.IsCSSNum():
+		n.unit = lit.Kind.String()
+	default:
+		return n, fmt.Errorf("%s is not a number", lit.Value)
+	}
+	var err error
+	n.f, err = strconv.ParseFloat(strings.TrimSuffix(lit.Value, n.unit), 64)
+	if err != nil {
+		return n, fmt.Errorf("%s is not a number", lit.Value)
+	}
+	return n, nil
+}
+
+// parseNumbers reads all args as numbers, units of all args
+// must match
+func parseNumbers(names []string, args []*ast.BasicLit) ([]number, error) {
+	nums := make([]number, len(args))
+	for i := range args {
+		var err error
+		nums[i], err = parseNumber(args[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", names[i], err)
+		}
+		if nums[i].unit != nums[0].unit {
+			return nil, fmt.Errorf("%s: %s and %s: %s have incompatible units",
+				names[0], args[0].Value, names[i], args[i].Value)
+		}
+	}
+	return nums, nil
+}
+
+// clamp restricts $number to the range between $min and $max
+func clamp(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	nums, err := parseNumbers([]string{"$min", "$number", "$max"}, args)
+	if err != nil {
+		return nil, err
+	}
+	min, num, max := nums[0], nums[1], nums[2]
+	switch {
+	case num.f < min.f:
+		return min.lit, nil
+	case num.f > max.f:
+		// min wins when min is greater than max
+		if min.f > max.f {
+			return min.lit, nil
+		}
+		return max.lit, nil
+	}
+	return num.lit, nil
+}
+
+// hypot returns the length of the n-dimensional vector with
+// components $numbers
+func hypot(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	names := make([]string, len(args))
+	for i := range names {
+		names[i] = "$numbers"
+	}
+	nums, err := parseNumbers(names, args)
+	if err != nil {
+		return nil, err
+	}
+	var sum float64
+	for _, n := range nums {
+		sum += n.f * n.f
+	}
+	return numberLit(math.Sqrt(sum), nums[0]), nil
+}
+
+// abs returns the absolute value of $number keeping its unit
+func abs(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	n, err := parseNumber(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("$number: %s", err)
+	}
+	if n.f >= 0 {
+		return n.lit, nil
+	}
+	return numberLit(-n.f, n), nil
+}
+
+// minimum returns the smallest of $numbers
+func minimum(env *builtin.Env, call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	return pick(args, token.LSS, env.Precision)
+}
+
+// maximum returns the largest of $numbers
+func maximum(env *builtin.Env, call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	return pick(args, token.GTR, env.Precision)
+}
+
+// pick returns the first of args for which no other arg compares
+// better by op. Compatible units are converted ie. max(1in, 5px),
+// unitless numbers compare with any unit. Numbers are compared at prec
+// decimal places.
+func pick(args []*ast.BasicLit, op token.Token, prec int) (*ast.BasicLit, error) {
+	if len(args) == 0 {
+		return nil, errors.New("at least one argument must be passed")
+	}
+	for _, arg := range args {
+		if _, err := parseNumber(arg); err != nil {
+			return nil, fmt.Errorf("$numbers: %s", err)
+		}
+	}
+	best := args[0]
+	for _, arg := range args[1:] {
+		better, err := ast.Compare(op, arg, best, prec)
+		if err != nil {
+			return nil, fmt.Errorf("$numbers: %s and $numbers: %s have incompatible units",
+				best.Value, arg.Value)
+		}
+		if better.Value == "true" {
+			best = arg
+		}
+	}
+	return best, nil
+}
+
+// numberLit creates a BasicLit from f using the unit of like
+func numberLit(f float64, like number) *ast.BasicLit {
+	lit := &ast.BasicLit{
+		Kind:     like.lit.Kind,
+		ValuePos: like.lit.Pos(),
+	}
+	// precision matches Sass
+	f = math.Round(f*1e10) / 1e10
+	lit.Value = strconv.FormatFloat(f, 'f', -1, 64) + like.unit
+	if len(like.unit) == 0 {
+		lit.Kind = token.FLOAT
+		if f == math.Trunc(f) {
+			lit.Kind = token.INT
+		}
+	}
+	return lit
+}
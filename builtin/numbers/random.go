@@ -0,0 +1,44 @@
+package numbers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.RegisterEnv("random($limit: null)", random)
+}
+
+// random returns a float between 0 and 1 or, when $limit is provided,
+// an integer between 1 and $limit
+func random(env *builtin.Env, call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	lit := &ast.BasicLit{
+		ValuePos: call.Pos(),
+	}
+	limit := args[0]
+	if limit.Kind == token.NULL {
+		// precision matches Sass
+		f := math.Round(env.Rand.Float64()*1e10) / 1e10
+		lit.Kind = token.FLOAT
+		lit.Value = strconv.FormatFloat(f, 'f', -1, 64)
+		return lit, nil
+	}
+	if limit.Kind != token.INT {
+		return nil, fmt.Errorf("$limit: %s is not a unitless integer", limit.Value)
+	}
+	n, err := strconv.Atoi(limit.Value)
+	if err != nil {
+		return nil, fmt.Errorf("$limit: %s is not a unitless integer", limit.Value)
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("$limit: must be greater than 0, was %d", n)
+	}
+	lit.Kind = token.INT
+	lit.Value = strconv.Itoa(env.Rand.Intn(n) + 1)
+	return lit, nil
+}
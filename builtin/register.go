@@ -2,7 +2,9 @@ package builtin
 
 import "github.com/wellington/sass/ast"
 
-// CallFunc describes a Sass function
+// CallFunc describes a Sass function working only on literals. It
+// remains for compatibility, functions accepting or returning lists
+// and maps should register a CallHandle with Reg.
 type CallFunc func(expr *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error)
 
 var reg func(s string, ch CallFunc, c CallHandle)
@@ -23,6 +25,7 @@ func BindRegister(old func(s string, ch CallFunc, c CallHandle)) {
 	}
 }
 
+// Register registers a CallFunc for use by parser
 func Register(s string, ch CallFunc) {
 	if reg != nil {
 		reg(s, ch, nil)
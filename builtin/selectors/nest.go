@@ -0,0 +1,33 @@
+package selectors
+
+import (
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/strops"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Register("selector-nest($selectors...)", nest)
+}
+
+// nest nests each selector within the previous one
+func nest(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	var sel string
+	for i, arg := range args {
+		s := strings.TrimSpace(strops.Unquote(arg.Value))
+		if i == 0 {
+			// the outermost selector has no parent
+			sel = ast.NestSelector("", s)
+			continue
+		}
+		sel = ast.NestSelector(sel, s)
+	}
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		Value:    sel,
+		ValuePos: call.Pos(),
+	}, nil
+}
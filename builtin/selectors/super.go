@@ -0,0 +1,50 @@
+package selectors
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/strops"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Register("is-superselector($super, $sub)", isSuper)
+	builtin.Reg("simple-selectors($selector)", simpleSelectors)
+}
+
+func isSuper(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	ok := ast.IsSuperselector(
+		strops.Unquote(args[0].Value),
+		strops.Unquote(args[1].Value),
+	)
+	return &ast.BasicLit{
+		Kind:     token.BOOL,
+		Value:    strconv.FormatBool(ok),
+		ValuePos: call.Pos(),
+	}, nil
+}
+
+// simpleSelectors returns a comma separated list of the simple
+// selectors in a compound selector
+func simpleSelectors(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	lit, ok := args[0].(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("$selector: %T is not a compound selector", args[0])
+	}
+	list := &ast.ListLit{
+		ValuePos: call.Pos(),
+		Comma:    true,
+		EndPos:   call.End(),
+	}
+	for _, s := range ast.SimpleSelectors(strops.Unquote(lit.Value)) {
+		list.Value = append(list.Value, &ast.BasicLit{
+			Kind:     token.STRING,
+			Value:    s,
+			ValuePos: lit.Pos(),
+		})
+	}
+	return list, nil
+}
@@ -0,0 +1,55 @@
+package selectors
+
+import (
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/strops"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Register("selector-append($selectors...)", appendSel)
+	builtin.Register("selector-unify($selector1, $selector2)", unify)
+}
+
+func selectorLit(call *ast.CallExpr, sel string) *ast.BasicLit {
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		Value:    sel,
+		ValuePos: call.Pos(),
+	}
+}
+
+// appendSel appends each selector to the previous one without
+// a descendant combinator
+func appendSel(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	var sel string
+	for i, arg := range args {
+		s := strings.TrimSpace(strops.Unquote(arg.Value))
+		if i == 0 {
+			sel = s
+			continue
+		}
+		sel = ast.AppendSelector(sel, s)
+	}
+	return selectorLit(call, sel), nil
+}
+
+// unify returns a selector matching elements matched by both
+// selectors, or null when none can be
+func unify(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	sel, ok := ast.UnifySelector(
+		strops.Unquote(args[0].Value),
+		strops.Unquote(args[1].Value),
+	)
+	if !ok {
+		return &ast.BasicLit{
+			Kind:     token.NULL,
+			Value:    "null",
+			ValuePos: call.Pos(),
+		}, nil
+	}
+	return selectorLit(call, sel), nil
+}
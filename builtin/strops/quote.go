@@ -1,6 +1,7 @@
 package strops
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/wellington/sass/ast"
@@ -10,12 +11,17 @@ import (
 )
 
 func init() {
-	builtin.Register("unquote($string)", unquote)
+	builtin.Reg("unquote($string)", unquote)
+	builtin.Register("quote($string)", quote)
 	builtin.Reg("length($value)", length)
 }
 
-func unquote(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
-	in := *args[0]
+func unquote(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	in, ok := args[0].(*ast.BasicLit)
+	if !ok {
+		// lists and maps have no quotes to remove
+		return args[0], nil
+	}
 	lit := &ast.BasicLit{
 		Kind:     token.STRING,
 		ValuePos: in.ValuePos,
@@ -26,8 +32,24 @@ func unquote(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
 	return lit, nil
 }
 
-func length(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+func quote(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	in := *args[0]
+	switch in.Kind {
+	case token.QSTRING, token.QSSTRING:
+		// already quoted
+		return &in, nil
+	case token.STRING:
+	default:
+		return nil, fmt.Errorf("$string: %s is not a string", in.Value)
+	}
+	return &ast.BasicLit{
+		Kind:     token.QSTRING,
+		ValuePos: in.ValuePos,
+		Value:    in.Value,
+	}, nil
+}
 
+func length(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
 	lit := &ast.BasicLit{
 		Kind:     token.INT,
 		Value:    "1",
@@ -37,6 +59,8 @@ func length(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
 	switch v := args[0].(type) {
 	case *ast.ListLit:
 		lit.Value = strconv.Itoa(len(v.Value))
+	case *ast.MapLit:
+		lit.Value = strconv.Itoa(len(v.Value))
 	}
 
 	return lit, nil
@@ -0,0 +1,74 @@
+package strops_test
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin/builtintest"
+	"github.com/wellington/sass/token"
+)
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		in string
+		e  string
+	}{
+		{`unquote("foo")`, "foo"},
+		{`unquote(foo)`, "foo"},
+		{`length(a)`, "1"},
+		{`length(1px 2px 3px)`, "3"},
+		{`length((a, b))`, "2"},
+		{`length((a: 1, b: 2))`, "2"},
+	}
+	for _, test := range tests {
+		lit, err := builtintest.CompileString(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lit.Value != test.e {
+			t.Errorf("%s got: %s wanted: %s", test.in, lit.Value, test.e)
+		}
+	}
+}
+
+func TestUnquote_list(t *testing.T) {
+	x, err := builtintest.CompileExpr(`unquote((a b c))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := x.(*ast.ListLit)
+	if !ok {
+		t.Fatalf("got: %T wanted: *ast.ListLit", x)
+	}
+	if e := 3; len(list.Value) != e {
+		t.Errorf("got: %d wanted: %d", len(list.Value), e)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in string
+		e  string
+	}{
+		{`quote(hi)`, "hi"},
+		{`quote("hi")`, "hi"},
+		{`quote(unquote("hi"))`, "hi"},
+	}
+	for _, test := range tests {
+		lit, err := builtintest.CompileString(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lit.Kind != token.QSTRING {
+			t.Errorf("%s got: %s wanted: %s", test.in, lit.Kind, token.QSTRING)
+		}
+		if lit.Value != test.e {
+			t.Errorf("%s got: %s wanted: %s", test.in, lit.Value, test.e)
+		}
+	}
+
+	_, err := builtintest.CompileString("quote(12px)")
+	if err == nil {
+		t.Error("expected error quoting a number")
+	}
+}
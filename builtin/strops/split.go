@@ -0,0 +1,59 @@
+package strops
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/strops"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.Reg("string.split($string, $separator, $limit: null)", split)
+}
+
+// split returns a bracketed comma list of the substrings of $string
+// separated by $separator. If $limit is provided, at most $limit
+// splits are performed.
+func split(call *ast.CallExpr, args ...ast.Expr) (ast.Expr, error) {
+	in, ok := args[0].(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("$string: % #v is not a string", args[0])
+	}
+	sep, ok := args[1].(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("$separator: % #v is not a string", args[1])
+	}
+
+	n := -1
+	if lim, ok := args[2].(*ast.BasicLit); ok && lim.Kind == token.INT {
+		i, err := strconv.Atoi(lim.Value)
+		if err != nil {
+			return nil, err
+		}
+		if i < 1 {
+			return nil, fmt.Errorf("$limit: must be greater than 0, was %d", i)
+		}
+		// limit is number of splits, SplitN wants number of substrings
+		n = i + 1
+	}
+
+	parts := strings.SplitN(strops.Unquote(in.Value),
+		strops.Unquote(sep.Value), n)
+	list := &ast.ListLit{
+		ValuePos: in.Pos(),
+		Comma:    true,
+		Bracket:  true,
+	}
+	for _, part := range parts {
+		list.Value = append(list.Value, &ast.BasicLit{
+			Kind:     token.QSTRING,
+			Value:    part,
+			ValuePos: in.Pos(),
+		})
+	}
+	return list, nil
+}
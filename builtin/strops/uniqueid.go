@@ -0,0 +1,20 @@
+package strops
+
+import (
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/token"
+)
+
+func init() {
+	builtin.RegisterEnv("unique-id()", uniqueID)
+}
+
+// uniqueID returns an unquoted string unique to this compilation
+func uniqueID(env *builtin.Env, call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		ValuePos: call.Pos(),
+		Value:    env.UniqueID(),
+	}, nil
+}
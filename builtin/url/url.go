@@ -2,6 +2,7 @@ package url
 
 import (
 	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/strops"
 	"github.com/wellington/sass/token"
 
 	"github.com/wellington/sass/builtin"
@@ -14,7 +15,7 @@ func init() {
 func url(call *ast.CallExpr, args ...*ast.BasicLit) (*ast.BasicLit, error) {
 	val := args[0].Value
 	if args[0].Kind == token.QSTRING {
-		val = `"` + val + `"`
+		val = strops.Wrap(val)
 	}
 	val = "url(" + val + ")"
 	lit := &ast.BasicLit{
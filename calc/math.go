@@ -3,18 +3,20 @@ package calc
 import (
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/wellington/sass/ast"
 	"github.com/wellington/sass/token"
 )
 
-// Resolve simple math to create a basic lit
-func Resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
-	return resolve(in, doOp)
+// Resolve simple math to create a basic lit, numbers are compared
+// at prec decimal places
+func Resolve(in ast.Expr, doOp bool, prec int) (*ast.BasicLit, error) {
+	return resolve(in, doOp, prec)
 }
 
-func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
+func resolve(in ast.Expr, doOp bool, prec int) (*ast.BasicLit, error) {
 	x := &ast.BasicLit{
 		ValuePos: in.Pos(),
 	}
@@ -23,7 +25,7 @@ func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
 	case *ast.StringExpr:
 		list := make([]string, 0, len(v.List))
 		for _, l := range v.List {
-			lit, err := resolve(l, doOp)
+			lit, err := resolve(l, doOp, prec)
 			if err != nil {
 				return nil, err
 			}
@@ -40,11 +42,11 @@ func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
 		var k token.Token
 		ss := make([]string, len(v.Value))
 		for i := range v.Value {
-			lit, err := resolve(v.Value[i], doOp)
-			k = lit.Kind
+			lit, err := resolve(v.Value[i], doOp, prec)
 			if err != nil {
 				return nil, err
 			}
+			k = lit.Kind
 			ss[i] = lit.Value
 		}
 		x = &ast.BasicLit{
@@ -55,10 +57,33 @@ func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
 		if len(v.Value) == 1 {
 			x.Kind = k
 		}
+	case *ast.MapLit:
+		// maps resolve to their inspect form ie. (a: 1, b: 2)
+		pairs := make([]string, len(v.Value))
+		for i, kv := range v.Value {
+			k, err := resolve(kv.Key, doOp, prec)
+			if err != nil {
+				return nil, err
+			}
+			val, err := resolve(kv.Value, doOp, prec)
+			if err != nil {
+				return nil, err
+			}
+			pairs[i] = k.Value + ": " + val.Value
+		}
+		x.Kind = token.STRING
+		x.Value = "(" + strings.Join(pairs, ", ") + ")"
 	case *ast.UnaryExpr:
-		x = v.X.(*ast.BasicLit)
+		switch v.Op {
+		case token.NOT:
+			x, err = not(v, doOp, prec)
+		case token.SUB:
+			x, err = negate(v, doOp, prec)
+		default:
+			x, err = resolve(v.X, doOp, prec)
+		}
 	case *ast.BinaryExpr:
-		x, err = binary(v, doOp)
+		x, err = binary(v, doOp, prec)
 	case *ast.BasicLit:
 		x = v
 	case *ast.Ident:
@@ -71,11 +96,15 @@ func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
 			log.Println("warning, resolution was attempted on an invalid value")
 			return x, nil
 		}
+		// mixin and function defaults are declared as the value
+		if lit, ok := v.Obj.Decl.(*ast.BasicLit); ok {
+			return lit, nil
+		}
 		rhs := v.Obj.Decl.(*ast.AssignStmt).Rhs
 		kind := token.INT
 		var val []string
 		for _, x := range rhs {
-			lit, err := resolve(x, doOp)
+			lit, err := resolve(x, doOp, prec)
 			if err != nil {
 				return nil, err
 			}
@@ -89,12 +118,12 @@ func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
 		x.Value = strings.Join(val, ", ")
 		x.Kind = kind
 	case *ast.CallExpr:
-		x, err = resolve(v.Resolved, doOp)
+		x, err = resolve(v.Resolved, doOp, prec)
 	case *ast.Interp:
 		if v.Obj == nil {
 			panic("unresolved interpolation")
 		}
-		x, err = resolve(v.Obj.Decl.(ast.Expr), doOp)
+		x, err = resolve(v.Obj.Decl.(ast.Expr), doOp, prec)
 	default:
 		err = fmt.Errorf("unsupported calc.resolve % #v\n", v)
 		panic(err)
@@ -103,7 +132,12 @@ func resolve(in ast.Expr, doOp bool) (*ast.BasicLit, error) {
 }
 
 // binary takes a BinaryExpr and simplifies it to a basiclit
-func binary(in *ast.BinaryExpr, doOp bool) (*ast.BasicLit, error) {
+func binary(in *ast.BinaryExpr, doOp bool, prec int) (*ast.BasicLit, error) {
+	switch in.Op {
+	case token.EQL, token.NEQ, token.LSS, token.GTR, token.LEQ, token.GEQ:
+		return compare(in, prec)
+	}
+
 	var hasList bool
 	// fuq, look for paren wrapped lists
 	if lit, ok := in.X.(*ast.ListLit); ok {
@@ -127,18 +161,15 @@ func binary(in *ast.BinaryExpr, doOp bool) (*ast.BasicLit, error) {
 		doOp = true
 	}
 
-	if _, ok := in.X.(*ast.Ident); ok {
-		doOp = true
-	}
-	if _, ok := in.Y.(*ast.Ident); ok {
+	if isValue(in.X) || isValue(in.Y) {
 		doOp = true
 	}
 
-	left, err := resolve(in.X, doOp)
+	left, err := resolve(in.X, doOp, prec)
 	if err != nil {
 		return nil, err
 	}
-	right, err := resolve(in.Y, doOp)
+	right, err := resolve(in.Y, doOp, prec)
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +177,11 @@ func binary(in *ast.BinaryExpr, doOp bool) (*ast.BasicLit, error) {
 		ValuePos: left.Pos(),
 		Kind:     token.STRING,
 	}
+	// Operators next to interpolation are kept as written ie. 1px - #{$x}
+	if isInterp(in.X) || isInterp(in.Y) {
+		out.Value = left.Value + " " + in.Op.String() + " " + right.Value
+		return out, nil
+	}
 	if doOp {
 		// So actually, we could be a valid type
 		out.Kind = left.Kind
@@ -153,13 +189,18 @@ func binary(in *ast.BinaryExpr, doOp bool) (*ast.BasicLit, error) {
 	switch in.Op {
 	case token.ADD, token.SUB, token.MUL, token.QUO:
 		return combineLits(in.Op, left, right, doOp)
-	case token.EQL:
-		out.Value = "false"
-		if left.Value == right.Value {
-			out.Value = "true"
-		} else {
-			log.Printf("not equal % #v: % #v\n", left, right)
+	case token.LAND:
+		// and returns the first falsey value, or the last value
+		if !Truthy(left) {
+			return left, nil
 		}
+		return right, nil
+	case token.LOR:
+		// or returns the first truthy value, or the last value
+		if Truthy(left) {
+			return left, nil
+		}
+		return right, nil
 	default:
 		fmt.Printf("l: % #v\nr: % #v\n", left, right)
 		err = fmt.Errorf("unsupported Operation %s", in.Op)
@@ -167,6 +208,153 @@ func binary(in *ast.BinaryExpr, doOp bool) (*ast.BasicLit, error) {
 	return out, err
 }
 
+// compare evaluates equality and relational operators, lists are
+// equal when each of their elements are equal
+func compare(in *ast.BinaryExpr, prec int) (*ast.BasicLit, error) {
+	if in.Op == token.EQL || in.Op == token.NEQ {
+		eq, err := equal(in.X, in.Y, prec)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.BasicLit{
+			Kind:     token.BOOL,
+			Value:    strconv.FormatBool(eq == (in.Op == token.EQL)),
+			ValuePos: in.Pos(),
+		}, nil
+	}
+	left, err := resolve(in.X, true, prec)
+	if err != nil {
+		return nil, err
+	}
+	right, err := resolve(in.Y, true, prec)
+	if err != nil {
+		return nil, err
+	}
+	return ast.Compare(in.Op, left, right, prec)
+}
+
+func equal(x, y ast.Expr, prec int) (bool, error) {
+	lx, xok := listOf(x)
+	ly, yok := listOf(y)
+	switch {
+	case xok && yok:
+		if len(lx.Value) != len(ly.Value) || lx.Comma != ly.Comma {
+			return false, nil
+		}
+		for i := range lx.Value {
+			eq, err := equal(lx.Value[i], ly.Value[i], prec)
+			if err != nil || !eq {
+				return false, err
+			}
+		}
+		return true, nil
+	case xok || yok:
+		return false, nil
+	}
+	left, err := resolve(x, true, prec)
+	if err != nil {
+		return false, err
+	}
+	right, err := resolve(y, true, prec)
+	if err != nil {
+		return false, err
+	}
+	return ast.Equal(left, right, prec), nil
+}
+
+// listOf finds the list x refers to. Lists of one element ie. (1)
+// are the element itself.
+func listOf(x ast.Expr) (*ast.ListLit, bool) {
+	switch v := x.(type) {
+	case *ast.ListLit:
+		if len(v.Value) == 1 {
+			return listOf(v.Value[0])
+		}
+		return v, true
+	case *ast.Ident:
+		if v.Obj == nil {
+			break
+		}
+		if assign, ok := v.Obj.Decl.(*ast.AssignStmt); ok &&
+			len(assign.Rhs) == 1 {
+			return listOf(assign.Rhs[0])
+		}
+	case *ast.CallExpr:
+		if v.Resolved != nil {
+			return listOf(v.Resolved)
+		}
+	}
+	return nil, false
+}
+
+// not resolves the operand of a not expression and negates it
+func not(in *ast.UnaryExpr, doOp bool, prec int) (*ast.BasicLit, error) {
+	x, err := resolve(in.X, doOp, prec)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.BasicLit{
+		Kind:     token.BOOL,
+		Value:    strconv.FormatBool(!Truthy(x)),
+		ValuePos: in.Pos(),
+	}, nil
+}
+
+// negate flips the sign of a number, anything else is prefixed
+// with - ie. -$x where $x: foo is -foo
+func negate(in *ast.UnaryExpr, doOp bool, prec int) (*ast.BasicLit, error) {
+	x, err := resolve(in.X, doOp, prec)
+	if err != nil {
+		return nil, err
+	}
+	lit := &ast.BasicLit{
+		Kind:     x.Kind,
+		ValuePos: in.Pos(),
+	}
+	switch {
+	case x.Kind == token.INT, x.Kind == token.FLOAT, x.Kind.IsCSSNum():
+		if strings.HasPrefix(x.Value, "-") {
+			lit.Value = x.Value[1:]
+		} else {
+			lit.Value = "-" + x.Value
+		}
+	default:
+		lit.Kind = token.STRING
+		lit.Value = "-" + x.Value
+	}
+	return lit, nil
+}
+
+// Truthy reports whether lit passes a condition, only false and null
+// are falsey
+func Truthy(lit *ast.BasicLit) bool {
+	switch lit.Kind {
+	case token.NULL:
+		return false
+	case token.BOOL:
+		return lit.Value != "false"
+	}
+	return true
+}
+
+// isValue reports whether x is a variable, function result or the
+// result of other math. Division is performed on these, literal
+// numbers ie. 16px/1.5 are left alone.
+func isValue(x ast.Expr) bool {
+	switch v := x.(type) {
+	case *ast.Ident, *ast.CallExpr:
+		return true
+	case *ast.BinaryExpr:
+		return v.Op != token.QUO
+	}
+	return false
+}
+
+func isInterp(x ast.Expr) bool {
+	_, ok := x.(*ast.Interp)
+	return ok
+}
+
 func combineLits(op token.Token, left, right *ast.BasicLit, force bool) (*ast.BasicLit, error) {
 	return ast.Op(op, left, right, force)
 
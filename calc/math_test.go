@@ -13,7 +13,7 @@ func TestBinary_simple_int(t *testing.T) {
 		Op: token.ADD,
 		Y:  &ast.BasicLit{Kind: token.INT, Value: "2"},
 	}
-	lit, err := binary(bin, true)
+	lit, err := binary(bin, true, ast.DefaultPrecision)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -34,7 +34,7 @@ func TestBinary_simple_str(t *testing.T) {
 		Op: token.ADD,
 		Y:  &ast.BasicLit{Kind: token.STRING, Value: "b"},
 	}
-	lit, err := binary(bin, true)
+	lit, err := binary(bin, true, ast.DefaultPrecision)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -52,7 +52,7 @@ func TestBinary_simple_str(t *testing.T) {
 		Op: token.ADD,
 		Y:  &ast.BasicLit{Kind: token.INT, Value: "1"},
 	}
-	lit, err = binary(bin, true)
+	lit, err = binary(bin, true, ast.DefaultPrecision)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -66,3 +66,43 @@ func TestBinary_simple_str(t *testing.T) {
 	}
 
 }
+
+func TestBinary_logical(t *testing.T) {
+	tru := &ast.BasicLit{Kind: token.BOOL, Value: "true"}
+	fal := &ast.BasicLit{Kind: token.BOOL, Value: "false"}
+	null := &ast.BasicLit{Kind: token.NULL, Value: "null"}
+	one := &ast.BasicLit{Kind: token.INT, Value: "1"}
+
+	tests := []struct {
+		x, y *ast.BasicLit
+		op   token.Token
+		e    string
+	}{
+		{tru, fal, token.LAND, "false"},
+		{fal, one, token.LAND, "false"},
+		{one, tru, token.LAND, "true"},
+		{fal, one, token.LOR, "1"},
+		{null, fal, token.LOR, "false"},
+		{one, fal, token.LOR, "1"},
+	}
+	for _, test := range tests {
+		lit, err := binary(&ast.BinaryExpr{
+			X: test.x, Op: test.op, Y: test.y,
+		}, true, ast.DefaultPrecision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lit.Value != test.e {
+			t.Errorf("%s %s %s got: %s wanted: %s",
+				test.x.Value, test.op, test.y.Value, lit.Value, test.e)
+		}
+	}
+
+	lit, err := resolve(&ast.UnaryExpr{Op: token.NOT, X: null}, true, ast.DefaultPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lit.Kind != token.BOOL || lit.Value != "true" {
+		t.Errorf("not null got: %s %s", lit.Kind, lit.Value)
+	}
+}
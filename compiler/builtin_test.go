@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/wellington/sass/token"
@@ -88,3 +89,387 @@ func TestBuiltin_nth(t *testing.T) {
 `
 	runParse(t, in, e)
 }
+
+func TestBuiltin_nth_map(t *testing.T) {
+	in := `$m: (a: 1px, b: 2px 3px);
+div {
+  c: nth($m, 2);
+}
+p {
+  @each $i in 1 2 {
+    span { d: nth($m, $i); }
+  }
+}`
+	e := `div {
+  c: b 2px 3px; }
+
+p span {
+  d: a 1px; }
+
+p span {
+  d: b 2px 3px; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_string_split(t *testing.T) {
+	in := `div {
+  a: string.split("a,b,c", ",");
+  b: string.split("a b c", " ", 1);
+}`
+	e := `div {
+  a: ["a", "b", "c"];
+  b: ["a", "b c"]; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_lighten(t *testing.T) {
+	in := `div {
+  a: lighten(#336699, 10%);
+  b: darken(#336699, 20%);
+}`
+	e := `div {
+  a: #4080bf;
+  b: #1a334d; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_adjust_color(t *testing.T) {
+	in := `div {
+  a: adjust-color(#102030, $red: 10, $blue: 5);
+  b: adjust-color(#102030, $hue: 60deg);
+  c: adjust-color(#102030, $blue: -100);
+  d: adjust-color(#102030, $alpha: -0.4);
+  e: scale-color(#102030, $lightness: 50%);
+  f: scale-color(#102030, $red: -50%, $blue: 50%);
+  g: change-color(#102030, $blue: 255);
+  h: change-color(#102030, $hue: 0, $saturation: 100%);
+  i: change-color(#102030, $alpha: 0.5);
+}`
+	e := `div {
+  a: #1a2035;
+  b: #201030;
+  c: #102000;
+  d: rgba(16, 32, 48, 0.6);
+  e: #5890c7;
+  f: #082098;
+  g: #1020ff;
+  h: #400000;
+  i: rgba(16, 32, 48, 0.5); }
+`
+	runParse(t, in, e)
+
+	for _, in := range []string{
+		"adjust-color(#102030, $red: 10, $hue: 10deg)",
+		"change-color(#102030, $blue: 10, $lightness: 10%)",
+		"scale-color(#102030, $hue: 10%)",
+	} {
+		ctx := NewContext()
+		_, err := ctx.runString("", "div { a: "+in+"; }")
+		if err == nil {
+			t.Errorf("%s expected error", in)
+		}
+	}
+}
+
+func TestBuiltin_ie_hex_str(t *testing.T) {
+	in := `div {
+  a: ie-hex-str(#abcdef);
+  b: ie-hex-str(rgba(0, 0, 0, 0.5));
+  c: ie-hex-str(#abc);
+}`
+	e := `div {
+  a: #FFABCDEF;
+  b: #80000000;
+  c: #FFAABBCC; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_math(t *testing.T) {
+	in := `div {
+  a: math.clamp(0, 5, 3);
+  b: math.hypot(3, 4);
+  c: math.clamp(1px, 0px, 3px);
+  d: math.hypot(1px, 1px);
+}`
+	e := `div {
+  a: 3;
+  b: 5;
+  c: 1px;
+  d: 1.41421px; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_abs_min_max(t *testing.T) {
+	in := `div {
+  a: abs(-10px);
+  b: abs(-.5em);
+  c: min(1em, 2em);
+  d: max(1px, 3px, 2px);
+  e: min(2px, 1);
+  f: max(1%, 2);
+  g: max(1in, 5px);
+  h: min(1cm, 5mm);
+  i: min(90deg, 1rad);
+}`
+	e := `div {
+  a: 10px;
+  b: 0.5em;
+  c: 1em;
+  d: 3px;
+  e: 1;
+  f: 2;
+  g: 1in;
+  h: 5mm;
+  i: 1rad; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_min_units(t *testing.T) {
+	ctx := NewContext()
+	in := `div {
+  a: min(1px, 1em);
+}`
+	_, err := ctx.runString("in.scss", in)
+	if err == nil {
+		t.Fatal("expected error for px and em")
+	}
+	e := "in.scss:2:9: $numbers: 1px and $numbers: 1em have incompatible units"
+	if err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+
+	_, err = ctx.runString("in.scss", "div {\n  a: max(1px, 1deg);\n}")
+	if err == nil {
+		t.Fatal("expected error for px and deg")
+	}
+}
+
+func TestBuiltin_unique_id(t *testing.T) {
+	in := `div {
+  a: unique-id();
+  b: unique-id();
+}`
+	run := func() string {
+		ctx := NewContext()
+		ctx.SetRandSeed(1)
+		out, err := ctx.runString("", in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	out := run()
+	if again := run(); out != again {
+		t.Errorf("same seed got:\n%s\nand:\n%s", out, again)
+	}
+
+	var a, b string
+	if _, err := fmt.Sscanf(out, "div {\n  a: %s\n  b: %s }", &a, &b); err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("ids are not unique: %s %s", a, b)
+	}
+	if a[0] < 'a' || a[0] > 'z' {
+		t.Errorf("id must start with a letter: %s", a)
+	}
+}
+
+func TestBuiltin_random(t *testing.T) {
+	in := `div {
+  a: random();
+  b: random(10);
+}`
+	run := func(seed int64) string {
+		ctx := NewContext()
+		ctx.SetRandSeed(seed)
+		out, err := ctx.runString("", in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	out := run(1)
+	if again := run(1); out != again {
+		t.Errorf("same seed got:\n%s\nand:\n%s", out, again)
+	}
+
+	var a float64
+	var b int
+	if _, err := fmt.Sscanf(out, "div {\n  a: %g;\n  b: %d; }", &a, &b); err != nil {
+		t.Fatal(err)
+	}
+	if a < 0 || a >= 1 {
+		t.Errorf("random() out of range: %g", a)
+	}
+	if b < 1 || b > 10 {
+		t.Errorf("random(10) out of range: %d", b)
+	}
+
+	for _, limit := range []string{"0", "1.5", "2px"} {
+		ctx := NewContext()
+		_, err := ctx.runString("", "div { a: random("+limit+"); }")
+		if err == nil {
+			t.Errorf("random(%s) expected error", limit)
+		}
+	}
+}
+
+func TestBuiltin_zip(t *testing.T) {
+	in := `div {
+  a: zip(1px 1px 3px, solid dashed solid, red green blue);
+  b: zip(1px 2px, solid dashed solid);
+  c: length(zip(a b c, d e));
+}`
+	e := `div {
+  a: 1px solid red, 1px dashed green, 3px solid blue;
+  b: 1px solid, 2px dashed;
+  c: 2; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_list_separator(t *testing.T) {
+	in := `div {
+  a: list-separator(a b);
+  b: list-separator((a, b));
+  c: list-separator(a);
+  d: set-nth(a b c, 2, x);
+  e: set-nth((a, b, c), 3, x);
+}`
+	e := `div {
+  a: space;
+  b: comma;
+  c: space;
+  d: a x c;
+  e: a, b, x; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	_, err := ctx.runString("", `div { a: set-nth(a b c, 4, x); }`)
+	if err == nil {
+		t.Error("expected error for out of range index")
+	}
+}
+
+func TestBuiltin_selector_nest(t *testing.T) {
+	in := `div {
+  a: selector-nest(".a", ".b", "&:hover");
+  b: selector-nest(".a, .b", ".c");
+}`
+	e := `div {
+  a: .a .b:hover;
+  b: .a .c, .b .c; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_selector_append_unify(t *testing.T) {
+	in := `div {
+  a: selector-append(".a", ".b");
+  b: selector-append(".a, .b", "__el");
+  c: selector-unify("a.x", ".y");
+  d: selector-unify("a", "b");
+}`
+	e := `div {
+  a: .a.b;
+  b: .a__el, .b__el;
+  c: a.x.y; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_simple_selectors(t *testing.T) {
+	in := `div {
+  a: simple-selectors(".foo.bar");
+  b: is-superselector(".a", "div .a.b");
+  c: is-superselector(".a.b", ".a");
+}`
+	e := `div {
+  a: .foo, .bar;
+  b: true;
+  c: false; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_feature_exists(t *testing.T) {
+	in := `div {
+  a: feature-exists(global-variable-shadowing);
+  b: feature-exists("at-error");
+  c: feature-exists(units-level-3);
+  d: feature-exists(foo);
+}`
+	e := `div {
+  a: true;
+  b: true;
+  c: true;
+  d: false; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_inspect_lists(t *testing.T) {
+	in := `$m: (a: 1, b: 2);
+div {
+  a: inspect("a");
+  b: inspect(a);
+  c: inspect(1px 2px);
+  d: inspect((1, 2));
+  e: inspect(null);
+  f: inspect($m);
+  g: inspect((1 2, 3 4));
+}`
+	e := `div {
+  a: "a";
+  b: a;
+  c: 1px 2px;
+  d: 1, 2;
+  e: null;
+  f: (a: 1, b: 2);
+  g: 1 2, 3 4; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_map_has_key(t *testing.T) {
+	in := `$m: (a: 1px, b: 2px 3px);
+$e: ();
+div {
+  a: map-has-key($m, a);
+  b: map-has-key($m, c);
+  c: map-has-key($e, a);
+  d: map-get($m, b);
+  e: length(());
+}`
+	e := `div {
+  a: true;
+  b: false;
+  c: false;
+  d: 2px 3px;
+  e: 0; }
+`
+	runParse(t, in, e)
+}
+
+func TestBuiltin_map_not_map(t *testing.T) {
+	ctx := NewContext()
+	in := `div {
+  a: map-has-key(a b, a);
+}`
+	_, err := ctx.runString("in.scss", in)
+	if err == nil {
+		t.Fatal("expected error for a list")
+	}
+	e := "in.scss:2:17: $map: a list is not a map"
+	if err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+}
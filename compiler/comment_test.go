@@ -0,0 +1,72 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/token"
+)
+
+func TestComment_mixed(t *testing.T) {
+	in := `// silent
+/* loud */
+div {
+  // inner silent
+  a: b; // trailing
+  /* inner loud */
+  c: d;
+}
+// last
+`
+	e := `/* loud */
+div {
+  a: b;
+  /* inner loud */
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestComment_compressed(t *testing.T) {
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	ctx.fset = token.NewFileSet()
+
+	in := `/* loud */
+div {
+  // silent
+  /* inner loud */
+  a: b;
+}
+`
+	e := `div{a:b}`
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestComment_nested(t *testing.T) {
+	in := `div {
+  /* parent */
+  p {
+/* multi
+ * line */
+    a: b;
+  }
+}
+/* trailing */
+`
+	e := `div {
+  /* parent */ }
+  div p {
+    /* multi
+     * line */
+    a: b; }
+
+/* trailing */
+`
+	runParse(t, in, e)
+}
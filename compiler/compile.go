@@ -3,22 +3,75 @@ package compiler
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
 	"github.com/wellington/sass/calc"
 	"github.com/wellington/sass/parser"
+	"github.com/wellington/sass/scanner"
+	"github.com/wellington/sass/strops"
 	"github.com/wellington/sass/token"
 )
 
+// Style is the output style of the compiled CSS
+type Style int
+
+const (
+	Nested     Style = iota // default, blocks are indented by depth
+	Compressed              // no whitespace or comments ie. a{b:c}
+)
+
+// IndentType is the character used to indent nested output
+type IndentType int
+
+const (
+	IndentSpace IndentType = iota // default, IndentWidth spaces per level
+	IndentTab                     // one tab per level
+)
+
 // Context maintains the state of the compiler and handles the output of the
 // parser.
 type Context struct {
+	// buf holds the output of the current declaration until it is
+	// flushed to w
 	buf      *bytes.Buffer
+	w        io.Writer
+	written  int // bytes flushed to w
+	newlines int // trailing newlines held back from w
 	fileName *ast.Ident
 	mode     parser.Mode
+	style    Style
+	strict   bool   // report invalid CSS instead of outputting it
+	indent   string // indention for each level of nesting
+
+	// precision is the number of decimal places numbers are
+	// rounded to in the output
+	precision int
+
+	indentType  IndentType
+	indentWidth int
+
+	// imports holds CSS @import rules, these are hoisted above
+	// all other rules in the output
+	imports []string
+
+	// env is shared by builtins during the compilation
+	env *builtin.Env
+	// vars are defined by SetVar
+	vars map[string]*ast.AssignStmt
+	// importCache is shared by the files of CompileFiles
+	importCache *scanner.Cache
+	// logOut receives output of @debug and @warn
+	logOut io.Writer
 
 	err error
 	// Records the current level of selectors
@@ -33,8 +86,8 @@ type Context struct {
 	// activeMedia maintains the current media query
 	// Once flushed, it should never be printed again
 	activeMedia *ast.BasicLit
-	// indicates that a media closing bracket needs to be
-	// flushed
+	// indicates that the media query was flushed and its closing
+	// bracket needs to be flushed
 	inMedia     bool
 	firstRule   bool // first rules print { otherwise don't
 	hiddenBlock bool // @each has hidden blocks, probably other examples of this
@@ -58,6 +111,138 @@ func Compile(input []byte) ([]byte, error) {
 
 }
 
+// Compile compiles input with the settings of ctx
+func (ctx *Context) Compile(input []byte) ([]byte, error) {
+	return ctx.run("", string(input))
+}
+
+// CompileTo compiles the Sass file at path with the settings of ctx,
+// the CSS is written to w as each declaration is compiled
+func (ctx *Context) CompileTo(w io.Writer, path string) error {
+	return ctx.runTo(w, path, nil)
+}
+
+// CompileTo compiles the Sass file at path, the CSS is written to w
+// as each declaration is compiled
+func CompileTo(w io.Writer, path string) error {
+	ctx := NewContext()
+	return ctx.runTo(w, path, nil)
+}
+
+// CompileFiles compiles each of the Sass files at paths with the
+// settings of ctx, out is called with the CSS of every file that
+// compiles. Files imported by more than one of them are read and
+// scanned once. A file failing to compile does not stop the others,
+// the failures are returned as a BatchError.
+func (ctx *Context) CompileFiles(paths []string, out func(path, css string)) error {
+	cache := scanner.NewCache()
+	errs := make(BatchError)
+	for _, path := range paths {
+		fc := ctx.fork()
+		fc.importCache = cache
+		css, err := fc.run(path, nil)
+		if err != nil {
+			errs[path] = err
+			continue
+		}
+		out(path, string(css))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// CompileFiles compiles each of the Sass files at paths, see
+// Context.CompileFiles
+func CompileFiles(paths []string, out func(path, css string)) error {
+	ctx := NewContext()
+	return ctx.CompileFiles(paths, out)
+}
+
+// CompileDir compiles the Sass files found in the tree at srcDir with
+// the settings of ctx, the CSS is written to outDir mirroring srcDir
+// ie. srcDir/a/b.scss => outDir/a/b.css. Partials ie. _vars.scss are
+// only compiled when imported. Files failing to compile or write are
+// returned as a BatchError keyed by source path.
+func (ctx *Context) CompileDir(srcDir, outDir string) error {
+	var paths []string
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), "_") {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".scss", ".sass":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	errs := make(BatchError)
+	err = ctx.CompileFiles(paths, func(path, css string) {
+		if err := writeCSS(srcDir, outDir, path, css); err != nil {
+			errs[path] = err
+		}
+	})
+	if batch, ok := err.(BatchError); ok {
+		for path, err := range batch {
+			errs[path] = err
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// CompileDir compiles the Sass files in srcDir to outDir, see
+// Context.CompileDir
+func CompileDir(srcDir, outDir string) error {
+	ctx := NewContext()
+	return ctx.CompileDir(srcDir, outDir)
+}
+
+// writeCSS writes css compiled from path within srcDir to the same
+// location within outDir
+func writeCSS(srcDir, outDir, path, css string) error {
+	rel, err := filepath.Rel(srcDir, path)
+	if err != nil {
+		return err
+	}
+	out := filepath.Join(outDir,
+		strings.TrimSuffix(rel, filepath.Ext(rel))+".css")
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(out, []byte(css), 0644)
+}
+
+// fork returns a new Context with the settings of ctx. The Env and
+// its import cache are shared.
+func (ctx *Context) fork() *Context {
+	c := NewContext()
+	c.mode = ctx.mode
+	c.style = ctx.style
+	c.strict = ctx.strict
+	c.precision = ctx.precision
+	c.indentType = ctx.indentType
+	c.indentWidth = ctx.indentWidth
+	c.indent = ctx.indent
+	c.env = ctx.env
+	c.logOut = ctx.logOut
+	c.vars = ctx.vars
+	for name, decl := range ctx.vars {
+		c.scope.SetGlobal(name, decl)
+	}
+	return c
+}
+
 // Run accepts a path to a Sass file and outputs a string
 func Run(path string) (string, error) {
 	ctx := NewContext()
@@ -75,28 +260,281 @@ func (ctx *Context) SetMode(mode parser.Mode) error {
 	return nil
 }
 
+// SetTrace enables printing a trace of the parsed productions. Trace
+// is off by default.
+func (ctx *Context) SetTrace(trace bool) error {
+	if trace {
+		ctx.mode |= parser.Trace
+	} else {
+		ctx.mode &^= parser.Trace
+	}
+	return nil
+}
+
+// SetStyle modifies the output style of the compiler. See Style for
+// available options
+func (ctx *Context) SetStyle(style Style) error {
+	if style != Nested && style != Compressed {
+		return fmt.Errorf("unsupported style %d", style)
+	}
+	ctx.style = style
+	return nil
+}
+
+// SetIndentType modifies the character used to indent nested blocks.
+// Spaces are used by default, see SetIndentWidth.
+func (ctx *Context) SetIndentType(typ IndentType) error {
+	if typ != IndentSpace && typ != IndentTab {
+		return fmt.Errorf("invalid indent type: %d", typ)
+	}
+	ctx.indentType = typ
+	ctx.indent = ctx.indention()
+	return nil
+}
+
+// SetIndentWidth modifies the number of spaces used for each level
+// of nesting. Width has no effect on IndentTab. Defaults to 2.
+func (ctx *Context) SetIndentWidth(width int) error {
+	if width < 0 {
+		return fmt.Errorf("invalid indent width: %d", width)
+	}
+	ctx.indentWidth = width
+	ctx.indent = ctx.indention()
+	return nil
+}
+
+// indention returns the string used for one level of nesting
+func (ctx *Context) indention() string {
+	if ctx.indentType == IndentTab {
+		return "\t"
+	}
+	return strings.Repeat(" ", ctx.indentWidth)
+}
+
+// SetStrict enables strict mode. In strict mode, declarations that
+// would generate invalid CSS are reported as errors.
+func (ctx *Context) SetStrict(strict bool) error {
+	ctx.strict = strict
+	return nil
+}
+
+// SetPrecision modifies the number of decimal places numbers are
+// rounded to in the output. Defaults to 5.
+func (ctx *Context) SetPrecision(precision int) error {
+	if precision < 0 {
+		return fmt.Errorf("invalid precision: %d", precision)
+	}
+	ctx.precision = precision
+	return nil
+}
+
+// SetRandSeed seeds the source of randomness used by builtins ie.
+// random() and unique-id(), making their output reproducible.
+func (ctx *Context) SetRandSeed(seed int64) error {
+	ctx.env = builtin.NewEnvSeed(seed)
+	return nil
+}
+
+// SetLogOutput modifies where @debug and @warn messages are written.
+// Defaults to os.Stderr.
+func (ctx *Context) SetLogOutput(w io.Writer) error {
+	ctx.logOut = w
+	return nil
+}
+
+// SetVar defines the variable name before compilation, value is
+// parsed as Sass ie. SetVar("$brand-color", "#3498db"). Declarations
+// with !default in the source do not override it.
+func (ctx *Context) SetVar(name, value string) error {
+	if !strings.HasPrefix(name, "$") || len(name) < 2 {
+		return fmt.Errorf("invalid variable name: %q", name)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), name,
+		name+": "+value+";", 0)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %s", name, err)
+	}
+	var decl *ast.AssignStmt
+	if len(f.Decls) == 1 {
+		if gen, ok := f.Decls[0].(*ast.GenDecl); ok && len(gen.Specs) == 1 {
+			if spec, ok := gen.Specs[0].(*ast.ValueSpec); ok {
+				decl, _ = spec.Names[0].Obj.Decl.(*ast.AssignStmt)
+			}
+		}
+	}
+	if decl == nil {
+		return fmt.Errorf("invalid value for %s: %q", name, value)
+	}
+	if ctx.vars == nil {
+		ctx.vars = make(map[string]*ast.AssignStmt)
+	}
+	ctx.vars[name] = decl
+	ctx.scope.SetGlobal(name, decl)
+	return nil
+}
+
 func (ctx *Context) runString(path string, src interface{}) (string, error) {
 	b, err := ctx.run(path, src)
 	return string(b), err
 }
 
 func (ctx *Context) run(path string, src interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ctx.runTo(&buf, path, src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
+// Parse parses the Sass file at path, or src when it is not nil, and
+// returns the AST without compiling it. The parser runs in the mode set
+// by SetMode. Positions in the AST are found in FileSet.
+//
+// Variables, function calls and math are evaluated while parsing, so
+// expressions in the AST carry their resolved values. File.Decls holds
+// the top-level declarations:
+//
+//	*ast.GenDecl     variable assignments and @import
+//	*ast.FuncDecl    @mixin and @function definitions
+//	*ast.SelDecl     selector blocks including placeholders ie. %name
+//	*ast.IfDecl      @if and its @else chain
+//	*ast.MediaDecl   @media blocks
+//	*ast.CommDecl    comments
+//	*ast.DebugDecl   @debug, @warn and @error
+//	*ast.AtRootDecl  @at-root
+//	*ast.AtRuleDecl  other at-rules ie. @font-face, @charset
+//
+func (ctx *Context) Parse(path string, src interface{}) (*ast.File, error) {
 	ctx.fset = token.NewFileSet()
-	// ctx.mode = parser.Trace
-	pf, err := parser.ParseFile(ctx.fset, path, src, ctx.mode)
+	ctx.env.Vars = ctx.vars
+	ctx.env.Precision = ctx.precision
+	// imports are cached for a single compilation unless shared
+	// by CompileFiles
+	ctx.env.Imports = ctx.importCache
+	if ctx.env.Imports == nil {
+		ctx.env.Imports = scanner.NewCache()
+	}
+	pf, err := parser.ParseFileEnv(ctx.fset, path, src, ctx.mode, ctx.env)
 	if err != nil {
-		return nil, err
+		return nil, toCompileError(err)
 	}
+	return pf, nil
+}
 
-	ast.Walk(ctx, pf)
-	lr, _ := utf8.DecodeLastRune(ctx.buf.Bytes())
-	_ = lr
-	if ctx.buf.Len() > 0 && lr != '\n' {
-		ctx.out("\n")
+// FileSet returns the positions of the last file parsed or compiled
+func (ctx *Context) FileSet() *token.FileSet {
+	return ctx.fset
+}
+
+func (ctx *Context) runTo(w io.Writer, path string, src interface{}) error {
+	pf, err := ctx.Parse(path, src)
+	if err != nil {
+		return err
+	}
+
+	// output state starts over when ctx compiles again
+	ctx.w = w
+	ctx.written = 0
+	ctx.newlines = 0
+	ctx.imports = nil
+	if err := ctx.hoistImports(pf); err != nil {
+		return err
+	}
+	for _, decl := range pf.Decls {
+		ast.Walk(ctx, decl)
+		if ctx.err != nil {
+			return ctx.err
+		}
+		if err := ctx.flush(); err != nil {
+			return err
+		}
 	}
 	// ctx.printSels(pf.Decls)
-	return ctx.buf.Bytes(), nil
+	return ctx.finish()
+}
+
+// flush writes the buffered output to w. Trailing newlines are held
+// back until more output follows them, see finish.
+func (ctx *Context) flush() error {
+	b := ctx.buf.Bytes()
+	trimmed := bytes.TrimRight(b, "\n")
+	defer ctx.buf.Reset()
+	if len(trimmed) == 0 {
+		ctx.newlines += len(b)
+		return nil
+	}
+	n, err := io.WriteString(ctx.w, strings.Repeat("\n", ctx.newlines))
+	ctx.written += n
+	if err != nil {
+		return err
+	}
+	ctx.newlines = len(b) - len(trimmed)
+	n, err = ctx.w.Write(trimmed)
+	ctx.written += n
+	return err
+}
+
+// finish ends the output. Nested output ends with a single newline,
+// compressed output ends with none.
+func (ctx *Context) finish() error {
+	if err := ctx.flush(); err != nil {
+		return err
+	}
+	ctx.newlines = 0
+	empty := ctx.written == 0 && len(ctx.imports) == 0
+	if empty || ctx.style == Compressed {
+		return nil
+	}
+	n, err := io.WriteString(ctx.w, "\n")
+	ctx.written += n
+	return err
+}
+
+// outLen is the length of all output, written or buffered
+func (ctx *Context) outLen() int {
+	return ctx.written + ctx.buf.Len()
+}
+
+// hoistImports writes CSS @import rules before all other rules,
+// CSS requires @import to precede all other rules
+func (ctx *Context) hoistImports(f *ast.File) error {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if imp, ok := spec.(*ast.ImportSpec); ok && imp.CSS {
+				ctx.imports = append(ctx.imports, cssImport(imp))
+			}
+		}
+	}
+	for i, imp := range ctx.imports {
+		if i > 0 && ctx.style != Compressed {
+			imp = "\n" + imp
+		}
+		// imports do not separate the rules that follow
+		if _, err := io.WriteString(ctx.w, imp); err != nil {
+			return err
+		}
+	}
+	if len(ctx.imports) > 0 && ctx.style != Compressed {
+		ctx.newlines = 1
+	}
+	return nil
+}
+
+// cssImport formats a CSS import, url() paths are output unquoted
+func cssImport(imp *ast.ImportSpec) string {
+	path := imp.Path.Value
+	if imp.Path.Kind != token.STRING {
+		path = strconv.Quote(path)
+	}
+	s := "@import " + path
+	if imp.Media != nil {
+		s += " " + imp.Media.Value
+	}
+	return s + ";"
 }
 
 // out prints with the appropriate indention, selectors always have indent
@@ -107,11 +545,11 @@ func (ctx *Context) out(v string) {
 		fmt.Fprintf(ctx.buf, v)
 		return
 	}
-	ws := []byte("                                              ")
 	lvl := ctx.level
-
-	format := append(ws[:lvl*2], "%s"...)
-	fmt.Fprintf(ctx.buf, string(format), v)
+	if lvl < 0 || ctx.style == Compressed {
+		lvl = 0
+	}
+	fmt.Fprint(ctx.buf, strings.Repeat(ctx.indent, lvl), v)
 }
 
 // This needs a new name, it prints on every stmt
@@ -119,34 +557,94 @@ func (ctx *Context) blockIntro() {
 
 	// this isn't a new block
 	if !ctx.firstRule {
-		fmt.Fprint(ctx.buf, "\n")
+		if ctx.style != Compressed {
+			fmt.Fprint(ctx.buf, "\n")
+		}
 		return
 	}
 
 	ctx.firstRule = false
 
 	// Only print newlines if there is text in the buffer
-	if ctx.buf.Len() > 0 {
+	if ctx.outLen() > 0 && ctx.style != Compressed {
 		if ctx.level == 0 {
 			fmt.Fprint(ctx.buf, "\n")
 		}
 	}
 
-	if ctx.activeMedia != nil {
+	if ctx.activeMedia != nil && !ctx.inMedia {
 		val := ctx.activeMedia.Value
-		ctx.activeMedia = nil
+		ctx.inMedia = true
 		// media queries have invalid indention, move up one
 		ctx.level--
-		ctx.out(fmt.Sprintf("%s {\n", val))
+		ctx.openBlock(val)
 		ctx.level++
 	}
 
 	sel := "MISSING"
 	if ctx.activeSel != nil {
-		sel = ctx.activeSel.Value
+		sel = ctx.formatSelector(ctx.activeSel.Value)
 	}
 
-	ctx.out(fmt.Sprintf("%s {\n", sel))
+	ctx.openBlock(sel)
+}
+
+// openBlock prints the selector or query starting a block
+func (ctx *Context) openBlock(sel string) {
+	if ctx.style == Compressed {
+		ctx.out(sel + "{")
+		return
+	}
+	ctx.out(sel + " {\n")
+}
+
+// closeBlock prints the end of a block. Compressed output drops the
+// semicolon of the last declaration ie. a{b:c;d:e}
+func (ctx *Context) closeBlock() {
+	if ctx.style == Compressed {
+		b := bytes.TrimRight(ctx.buf.Bytes(), ";\n")
+		ctx.buf.Truncate(len(b))
+		fmt.Fprint(ctx.buf, "}")
+		return
+	}
+	fmt.Fprint(ctx.buf, " }\n")
+}
+
+// formatSelector formats a selector list for the output style,
+// compressed output drops the whitespace after the commas ie.
+// h1, h2 => h1,h2. Commas inside of parens, brackets and strings are
+// left untouched.
+func (ctx *Context) formatSelector(s string) string {
+	if ctx.style != Compressed {
+		return s
+	}
+	var buf bytes.Buffer
+	var depth int
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		buf.WriteByte(ch)
+		switch {
+		case quote != 0:
+			if ch == '\\' && i+1 < len(s) {
+				i++
+				buf.WriteByte(s[i])
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '(' || ch == '[':
+			depth++
+		case ch == ')' || ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			for i+1 < len(s) && s[i+1] == ' ' {
+				i++
+			}
+		}
+	}
+	return buf.String()
 }
 
 func (ctx *Context) blockOutro() {
@@ -160,13 +658,8 @@ func (ctx *Context) blockOutro() {
 	}
 
 	ctx.firstRule = true
-	buf := " }\n"
-	if ctx.inMedia {
-		ctx.inMedia = false
-		buf = " }" + buf
-	}
 	// if !skipParen {
-	fmt.Fprintf(ctx.buf, buf)
+	ctx.closeBlock()
 	// }
 }
 
@@ -180,15 +673,18 @@ func (ctx *Context) Visit(node ast.Node) ast.Visitor {
 	var key ast.Node
 	switch v := node.(type) {
 	case *ast.BlockStmt:
+		// hidden only applies to this block, not nested blocks
+		hidden := ctx.hiddenBlock
+		ctx.hiddenBlock = false
 		if (ctx.scope.RuleLen() > 0 || ctx.activeMedia != nil) &&
-			!ctx.hiddenBlock {
+			!hidden {
 			ctx.level = ctx.level + 1
 			if !ctx.firstRule {
-				fmt.Fprintf(ctx.buf, " }\n")
+				ctx.closeBlock()
 			}
 		}
 		ctx.scope = NewScope(ctx.scope)
-		if !ctx.hiddenBlock {
+		if !hidden {
 			ctx.firstRule = true
 		}
 		for _, node := range v.List {
@@ -198,11 +694,10 @@ func (ctx *Context) Visit(node ast.Node) ast.Visitor {
 			ctx.level = ctx.level - 1
 		}
 		ctx.scope = CloseScope(ctx.scope)
-		if !ctx.hiddenBlock {
+		if !hidden {
 			ctx.blockOutro()
 			ctx.firstRule = true
 		}
-		ctx.hiddenBlock = false
 		// ast.Walk(ctx, v.List)
 		// fmt.Fprintf(ctx.buf, "}")
 		return nil
@@ -224,11 +719,24 @@ func (ctx *Context) Visit(node ast.Node) ast.Visitor {
 	case *ast.RuleSpec:
 		key = ruleSpec
 	case *ast.SelStmt:
+		// Placeholder selectors are never printed
+		if v.Resolved != nil && len(v.Resolved.Value) == 0 {
+			return nil
+		}
 		// We will need to combine parent selectors
 		// while printing these
 		key = selStmt
 		// Nothing to do
 	case *ast.CommStmt:
+	case *ast.CommDecl:
+		ctx.printers[commDecl](ctx, node)
+		return nil
+	case *ast.DebugDecl:
+		ctx.printers[debugStmt](ctx, v.DebugStmt)
+		return nil
+	case *ast.DebugStmt:
+		ctx.printers[debugStmt](ctx, node)
+		return nil
 	case *ast.CommentGroup:
 	case *ast.Comment:
 		key = comment
@@ -241,16 +749,31 @@ func (ctx *Context) Visit(node ast.Node) ast.Visitor {
 	case *ast.CallExpr:
 	case nil:
 		return ctx
+	case *ast.MediaDecl:
+		// Top level @media has no parent rules, so the block
+		// does not indent
+		ctx.hiddenBlock = true
 	case *ast.MediaStmt:
-		fmt.Println("mediastmt")
-		key = mediaStmt
+		ctx.printers[mediaStmt](ctx, node)
+		return nil
+	case *ast.AtRuleDecl, *ast.AtRuleStmt:
+		ctx.printers[atRuleStmt](ctx, node)
+		return nil
+	case *ast.AtRootDecl, *ast.AtRootStmt:
+		ctx.printers[atRootStmt](ctx, node)
+		return nil
 	case *ast.EmptyStmt:
 	case *ast.AssignStmt:
 		key = assignStmt
 	case *ast.EachStmt:
 		key = eachStmt
-	case *ast.ListLit:
+	case *ast.ListLit, *ast.MapLit, *ast.StringExpr:
+		// values are printed by their declarations, don't walk
+		// into their elements
+		return nil
 	case *ast.ImportSpec:
+		// CSS imports were hoisted before walking
+	case *ast.ExtendStmt:
 	case *ast.IfDecl:
 	case *ast.IfStmt:
 		key = ifStmt
@@ -274,9 +797,13 @@ var (
 	propSpec    *ast.PropValueSpec
 	typeSpec    *ast.TypeSpec
 	comment     *ast.Comment
+	commDecl    *ast.CommDecl
 	funcDecl    *ast.FuncDecl
 	includeSpec *ast.IncludeSpec
+	debugStmt   *ast.DebugStmt
 	mediaStmt   *ast.MediaStmt
+	atRuleStmt  *ast.AtRuleStmt
+	atRootStmt  *ast.AtRootStmt
 	eachStmt    *ast.EachStmt
 	ifStmt      *ast.IfStmt
 )
@@ -284,6 +811,14 @@ var (
 func (ctx *Context) init() {
 	ctx.buf = bytes.NewBuffer(nil)
 	ctx.printers = make(map[ast.Node]func(*Context, ast.Node))
+	ctx.firstRule = true
+	ctx.mode = parser.ParseComments
+	ctx.env = builtin.NewEnv()
+	ctx.logOut = os.Stderr
+	ctx.indentType = IndentSpace
+	ctx.indentWidth = 2
+	ctx.indent = ctx.indention()
+	ctx.precision = ast.DefaultPrecision
 	ctx.printers[valueSpec] = visitValueSpec
 	ctx.printers[funcDecl] = visitFunc
 	ctx.printers[assignStmt] = visitAssignStmt
@@ -296,18 +831,75 @@ func (ctx *Context) init() {
 	ctx.printers[propSpec] = printPropValueSpec
 	ctx.printers[expr] = printExpr
 	ctx.printers[comment] = printComment
+	ctx.printers[commDecl] = printCommDecl
 	ctx.printers[mediaStmt] = printMedia
+	ctx.printers[atRuleStmt] = printAtRule
+	ctx.printers[atRootStmt] = printAtRoot
 	ctx.printers[eachStmt] = printEach
+	ctx.printers[debugStmt] = printDebug
 	ctx.scope = NewScope(empty)
 	// ctx.printers[typeSpec] = visitTypeSpec
 	// assign printers
 }
 
+// silent reports whether the comment should be removed from the output.
+// Silent comments are never output, loud comments are removed
+// in compressed output.
+func (ctx *Context) silent(cmt *ast.Comment) bool {
+	return cmt.Tok == token.LINECOMMENT ||
+		strings.HasPrefix(cmt.Text, "//") ||
+		ctx.style == Compressed
+}
+
+// printCommDecl prints comments found outside of selectors, these
+// are not part of any block.
+func printCommDecl(ctx *Context, n ast.Node) {
+	decl := n.(*ast.CommDecl)
+	for _, cmt := range decl.Group.List {
+		if ctx.silent(cmt) {
+			continue
+		}
+		if ctx.outLen() > 0 {
+			fmt.Fprint(ctx.buf, "\n")
+		}
+		col := ctx.fset.Position(cmt.Pos()).Column
+		ctx.out(strings.Join(commentLines(cmt.Text, col), "\n"))
+	}
+}
+
 func printComment(ctx *Context, n ast.Node) {
-	ctx.blockIntro()
 	cmt := n.(*ast.Comment)
-	// These additional spaces should be handled by out()
-	ctx.out("  " + cmt.Text)
+	if ctx.silent(cmt) {
+		return
+	}
+	ctx.blockIntro()
+	// Comments are content, the block must be closed before
+	// any nested blocks
+	ctx.scope.RuleAdd(nil)
+	col := ctx.fset.Position(cmt.Pos()).Column
+	for i, line := range commentLines(cmt.Text, col) {
+		if i > 0 {
+			fmt.Fprint(ctx.buf, "\n")
+		}
+		// These additional spaces should be handled by out()
+		ctx.out(ctx.indent + line)
+	}
+}
+
+// commentLines splits a multi-line comment. Interior lines are
+// stripped of the indention of the comment ie. col, so that they
+// can be indented to the output level.
+func commentLines(text string, col int) []string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		j := 0
+		for j < col-1 && j < len(line) && (line[j] == ' ' || line[j] == '\t') {
+			j++
+		}
+		lines[i] = line[j:]
+	}
+	return lines
 }
 
 func printExpr(ctx *Context, n ast.Node) {
@@ -339,16 +931,81 @@ func printRuleSpec(ctx *Context, n ast.Node) {
 	// Inspect the sel buffer and dump it
 	// Also need to track what level was last dumped
 	// so selectors don't get printed twice
-	ctx.blockIntro()
-
 	spec := n.(*ast.RuleSpec)
+	values, important := splitImportant(spec.Values)
+	// declarations without a value ie. width: ;
+	values = dropNil(values)
+	if ctx.strict {
+		if len(values) == 0 {
+			ctx.err = ctx.validValue(spec, "")
+			return
+		}
+		if ctx.err = ctx.validUnits(values); ctx.err != nil {
+			return
+		}
+	}
+	s, err := simplifyExprs(ctx, values)
+	if err != nil {
+		ctx.err = ctx.errorf(spec.Name.Pos(), "%s", err)
+		return
+	}
+	// declarations with a null value are omitted
+	if len(s) == 0 {
+		return
+	}
+
+	ctx.blockIntro()
 	ctx.scope.RuleAdd(spec)
-	ctx.out(fmt.Sprintf("  %s: ", spec.Name))
-	var s string
-	s, ctx.err = simplifyExprs(ctx, spec.Values)
+	if ctx.style == Compressed {
+		ctx.out(fmt.Sprintf("%s:", spec.Name))
+	} else {
+		ctx.out(fmt.Sprintf("%s%s: ", ctx.indent, spec.Name))
+	}
+	if important {
+		if ctx.style != Compressed {
+			s += " "
+		}
+		s += token.IMPORTANT.String()
+	}
 	fmt.Fprintf(ctx.buf, "%s;", s)
 }
 
+// printDebug writes @debug and @warn messages to the log output,
+// these never appear in the CSS. @error stops the compilation.
+func printDebug(ctx *Context, n ast.Node) {
+	stmt := n.(*ast.DebugStmt)
+	var s string
+	var err error
+	// strings are reported without quotes
+	switch v := stmt.X.(type) {
+	case *ast.StringExpr:
+		s, err = simplifyExprs(ctx, v.List)
+	case *ast.BasicLit:
+		s = v.Value
+	default:
+		s, err = resolveExpr(ctx, v, true)
+	}
+	if err != nil {
+		ctx.err = ctx.errorf(stmt.Pos(), "%s", err)
+		return
+	}
+
+	pos := ctx.fset.Position(stmt.Pos())
+	name := pos.Filename
+	if len(name) == 0 {
+		name = "stdin"
+	}
+	switch stmt.Tok {
+	case token.DEBUG:
+		fmt.Fprintf(ctx.logOut, "%s:%d DEBUG: %s\n", name, pos.Line, s)
+	case token.WARN:
+		fmt.Fprintf(ctx.logOut, "WARNING: %s\n         on line %d of %s\n\n",
+			s, pos.Line, name)
+	case token.ERROR:
+		ctx.err = ctx.errorf(stmt.Pos(), "%s", s)
+	}
+}
+
 func printEach(ctx *Context, n ast.Node) {
 	// surprise, not media but behavior is same!
 	ctx.hiddenBlock = true
@@ -358,8 +1015,122 @@ func printEach(ctx *Context, n ast.Node) {
 
 func printMedia(ctx *Context, n ast.Node) {
 	stmt := n.(*ast.MediaStmt)
-	ctx.activeMedia = stmt.Query
-	ctx.inMedia = true
+	ctx.printBubble(stmt.Query, stmt.Body)
+}
+
+// printBubble prints the rules in body inside of the block opened by
+// query ie. @media print. Parent selectors are repeated inside
+// the block.
+func (ctx *Context) printBubble(query *ast.BasicLit, body *ast.BlockStmt) {
+	ctx.activeMedia = query
+	if !hasDeclStmt(body) {
+		// nested selectors indent themselves
+		ctx.hiddenBlock = true
+	}
+	ast.Walk(ctx, body)
+	if ctx.inMedia {
+		// close the query along with the last rule
+		if bytes.HasSuffix(ctx.buf.Bytes(), []byte("\n")) {
+			ctx.buf.Truncate(ctx.buf.Len() - 1)
+		}
+		ctx.closeBlock()
+	}
+	// An empty @media never flushes the query, drop it so it
+	// isn't printed by the next rule
+	ctx.activeMedia = nil
+	ctx.inMedia = false
+}
+
+// printAtRule prints at-rules passed through to CSS. At the root,
+// at-rules holding only declarations ie. @font-face print like a
+// selector. Otherwise they bubble up like @media.
+func printAtRule(ctx *Context, n ast.Node) {
+	var stmt *ast.AtRuleStmt
+	var root bool
+	switch v := n.(type) {
+	case *ast.AtRuleDecl:
+		stmt, root = v.AtRuleStmt, true
+	case *ast.AtRuleStmt:
+		stmt = v
+	}
+	if stmt.Body == nil {
+		fmt.Fprintf(ctx.buf, "%s;", stmt.Rule.Value)
+		if ctx.style != Compressed {
+			fmt.Fprint(ctx.buf, "\n")
+		}
+		return
+	}
+
+	if root && !hasSelStmt(stmt.Body) {
+		sel := ctx.activeSel
+		ctx.activeSel = stmt.Rule
+		ast.Walk(ctx, stmt.Body)
+		ctx.activeSel = sel
+		return
+	}
+
+	ctx.printBubble(stmt.Rule, stmt.Body)
+}
+
+// printAtRoot prints the rules of @at-root at the root of the output
+// or of the enclosing @media. The parser has already removed the
+// parent selectors from them.
+func printAtRoot(ctx *Context, n ast.Node) {
+	var stmt *ast.AtRootStmt
+	switch v := n.(type) {
+	case *ast.AtRootDecl:
+		stmt = v.AtRootStmt
+	case *ast.AtRootStmt:
+		stmt = v
+	}
+	media := ctx.activeMedia
+	noMedia := media != nil && stmt.Excludes("media")
+	if !noMedia && !stmt.Excludes("rule") {
+		ast.Walk(ctx, stmt.Body)
+		return
+	}
+
+	// close the parent rule and query, the rules that follow
+	// reopen them
+	if !ctx.firstRule {
+		ctx.closeBlock()
+		ctx.firstRule = true
+	}
+	if noMedia {
+		if ctx.inMedia {
+			if bytes.HasSuffix(ctx.buf.Bytes(), []byte("\n")) {
+				ctx.buf.Truncate(ctx.buf.Len() - 1)
+			}
+			ctx.closeBlock()
+		}
+		ctx.activeMedia, ctx.inMedia = nil, false
+	}
+
+	sel, scope, level := ctx.activeSel, ctx.scope, ctx.level
+	ctx.scope, ctx.level = NewScope(scope), 0
+	for _, s := range stmt.Body.List {
+		ast.Walk(ctx, s)
+	}
+	ctx.activeSel, ctx.scope, ctx.level = sel, scope, level
+	ctx.activeMedia = media
+}
+
+func hasSelStmt(block *ast.BlockStmt) bool {
+	for _, stmt := range block.List {
+		if _, ok := stmt.(*ast.SelStmt); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDeclStmt(block *ast.BlockStmt) bool {
+	for _, stmt := range block.List {
+		if _, ok := stmt.(*ast.DeclStmt); ok {
+			return true
+		}
+	}
+	return false
 }
 
 func printPropValueSpec(ctx *Context, n ast.Node) {
@@ -373,7 +1144,8 @@ func printIfStmt(ctx *Context, n ast.Node) {
 	if err != nil {
 		log.Fatal("failed to resolve @if", err)
 	}
-	if s == "true" {
+	// false and null, which prints as nothing, are falsey
+	if s != "false" && len(s) > 0 {
 		ctx.Visit(ifStmt.Body)
 	} else {
 		ctx.Visit(ifStmt.Else)
@@ -382,44 +1154,67 @@ func printIfStmt(ctx *Context, n ast.Node) {
 
 // Variable assignments inside blocks ie. mixins
 func visitAssignStmt(ctx *Context, n ast.Node) {
-	fmt.Println("visit Assign")
-	return
 	stmt := n.(*ast.AssignStmt)
-	var key, val *ast.Ident
-	_, _ = key, val
-	switch v := stmt.Lhs[0].(type) {
-	case *ast.Ident:
-		key = v
-	default:
-		log.Fatalf("unsupported key: % #v", v)
+	for _, x := range stmt.Lhs {
+		if ident, ok := x.(*ast.Ident); ok {
+			ctx.setVar(ident, stmt)
+		}
 	}
+}
 
-	switch v := stmt.Rhs[0].(type) {
-	case *ast.Ident:
-		val = v
-	default:
-		log.Fatalf("unsupported key: % #v", v)
+// Variable declarations
+func visitValueSpec(ctx *Context, n ast.Node) {
+	spec := n.(*ast.ValueSpec)
+	for _, name := range spec.Names {
+		ctx.setVar(name, spec)
 	}
+}
 
+// setVar assigns the variable ident, !global assignments are made in
+// the outermost scope
+func (ctx *Context) setVar(ident *ast.Ident, v interface{}) {
+	if ident.Global {
+		ctx.scope.SetGlobal(ident.Name, v)
+		return
+	}
+	ctx.scope.Set(ident.Name, v)
 }
 
-// Variable declarations
-func visitValueSpec(ctx *Context, n ast.Node) {
-	return
+// lookupVar resolves the variable name through the current scope,
+// declarations are resolved when looked up
+func (ctx *Context) lookupVar(name string) (string, bool) {
+	switch v := ctx.scope.Lookup(name).(type) {
+	case *ast.ValueSpec:
+		s, err := simplifyExprs(ctx, v.Values)
+		return s, err == nil
+	case *ast.AssignStmt:
+		return joinLits(resolveAssign(ctx, v), " "), true
+	case string:
+		return v, true
+	}
+	return "", false
 }
 
-func calculateExprs(ctx *Context, bin *ast.BinaryExpr, doOp bool) (string, error) {
+func calculateExprs(ctx *Context, x ast.Expr, doOp bool) (string, error) {
 
-	lit, err := calc.Resolve(bin, doOp)
+	lit, err := calc.Resolve(x, doOp, ctx.precision)
 	if err != nil {
 		return "", err
 	}
-	return lit.Value, nil
+	if lit.Kind == token.NULL {
+		return "", nil
+	}
+	return ctx.formatNumber(lit), nil
 }
 
 func resolveIdent(ctx *Context, ident *ast.Ident) (out string) {
 	v := ident
+	// The parser resolves most variables lexically, those it could
+	// not resolve are found in the scope they are printed in.
 	if ident.Obj == nil {
+		if s, ok := ctx.lookupVar(ident.Name); ok {
+			return s
+		}
 		out = ident.Name
 		return
 	}
@@ -442,9 +1237,22 @@ func resolveIdent(ctx *Context, ident *ast.Ident) (out string) {
 				}
 				continue
 			}
-			lit := vv.Values[i].(*ast.BasicLit)
-			if len(lit.Value) > 0 {
-				s = append(s, lit.Value)
+			var val string
+			switch x := vv.Values[i].(type) {
+			case *ast.BasicLit:
+				if x.Kind != token.NULL {
+					val = ctx.formatNumber(x)
+				}
+			default:
+				// lists keep their separator
+				var err error
+				val, err = resolveExpr(ctx, x, false)
+				if err != nil {
+					ctx.err = err
+				}
+			}
+			if len(val) > 0 {
+				s = append(s, val)
 			}
 		}
 		out = strings.Join(s, " ")
@@ -464,9 +1272,12 @@ func resolveIdent(ctx *Context, ident *ast.Ident) (out string) {
 
 // joinLits acts like strings.Join
 func joinLits(a []*ast.BasicLit, sep string) string {
-	s := make([]string, len(a))
+	s := make([]string, 0, len(a))
 	for i := range a {
-		s[i] = a[i].Value
+		if a[i].Kind == token.NULL {
+			continue
+		}
+		s = append(s, a[i].Value)
 	}
 	return strings.Join(s, sep)
 }
@@ -479,23 +1290,39 @@ func resolveAssign(ctx *Context, astmt *ast.AssignStmt) (lits []*ast.BasicLit) {
 			assign := v.Obj.Decl.(*ast.AssignStmt)
 			// Replace Ident with underlying BasicLit
 			lits = append(lits, resolveAssign(ctx, assign)...)
-		case *ast.CallExpr:
-			lits = append(lits, v.Fun.(*ast.Ident).Obj.Decl.(*ast.BasicLit))
+		case *ast.CallExpr, *ast.BinaryExpr, *ast.UnaryExpr:
+			out, err := resolveExpr(ctx, v, false)
+			if err != nil {
+				ctx.err = ctx.errorf(v.Pos(), "%s", err)
+				return nil
+			}
+			lits = append(lits, &ast.BasicLit{
+				Kind:     token.STRING,
+				ValuePos: v.Pos(),
+				Value:    out,
+			})
 		case *ast.BasicLit:
-			lits = append(lits, v)
+			lits = append(lits, &ast.BasicLit{
+				Kind:     v.Kind,
+				ValuePos: v.Pos(),
+				Value:    ctx.formatNumber(v),
+			})
 		case *ast.StringExpr:
 			list := make([]*ast.BasicLit, len(v.List))
 			for i := range v.List {
 				list[i] = v.List[i].(*ast.BasicLit)
 			}
-			list[0].Value = `"` + list[0].Value
-			last := len(list) - 1
-			list[last].Value = list[last].Value + `"`
-			lits = append(lits, list...)
-		case *ast.ListLit:
-			out, err := simplifyExprs(ctx, v.Value)
+			lits = append(lits, &ast.BasicLit{
+				Kind:     token.QSTRING,
+				ValuePos: v.Pos(),
+				Value:    strops.Wrap(joinLits(list, "")),
+			})
+		case *ast.ListLit, *ast.MapLit:
+			// resolve the list as a whole to keep its separator
+			out, err := resolveExpr(ctx, v, false)
 			if err != nil {
-				log.Fatal(err)
+				ctx.err = ctx.errorf(v.Pos(), "%s", err)
+				return nil
 			}
 			lits = append(lits, &ast.BasicLit{
 				Value: out,
@@ -515,15 +1342,24 @@ func resolveExpr(ctx *Context, expr ast.Expr, doOp bool) (out string, err error)
 		panic("ast.Value")
 	case *ast.BinaryExpr:
 		out, err = calculateExprs(ctx, v, doOp)
+	case *ast.UnaryExpr:
+		if v.Op != token.NOT && v.Op != token.SUB && v.Op != token.ADD {
+			panic(fmt.Sprintf("unhandled expr: % #v\n", v))
+		}
+		out, err = calculateExprs(ctx, v, doOp)
 	case *ast.CallExpr:
 		fn, ok := v.Fun.(*ast.Ident)
 		if !ok {
 			return "", fmt.Errorf("unable to read func: % #v", v.Fun)
 		}
-		return resolveExpr(ctx, fn.Obj.Decl.(ast.Expr), doOp)
+		// the parser evaluates calls, use the value it returned
+		if v.Resolved == nil {
+			return "", fmt.Errorf("unresolved function call %s", fn.Name)
+		}
+		return resolveExpr(ctx, v.Resolved, doOp)
 	case *ast.StringExpr:
 		out, err = simplifyExprs(ctx, v.List)
-		return `"` + out + `"`, nil
+		return strops.Wrap(out), nil
 	case *ast.ParenExpr:
 		out, ctx.err = simplifyExprs(ctx, []ast.Expr{v.X})
 	case *ast.Ident:
@@ -531,14 +1367,15 @@ func resolveExpr(ctx *Context, expr ast.Expr, doOp bool) (out string, err error)
 	case *ast.BasicLit:
 		switch v.Kind {
 		case token.VAR:
-			// s, ok := ctx.scope.Lookup(v.Value).(string)
-			// if ok {
-			// 	sums = append(sums, s)
-			// }
+			out, _ = ctx.lookupVar(v.Value)
 		case token.QSTRING:
-			out = `"` + v.Value + `"`
+			out = strops.Wrap(v.Value)
+		case token.COLOR:
+			out = ctx.formatColor(v.Value)
+		case token.NULL:
+			// null is never printed
 		default:
-			out = v.Value
+			out = ctx.formatNumber(v)
 		}
 	case *ast.ListLit:
 		vals := make([]string, len(v.Value))
@@ -546,12 +1383,35 @@ func resolveExpr(ctx *Context, expr ast.Expr, doOp bool) (out string, err error)
 		if v.Comma {
 			delim = ", "
 		}
-		for i, x := range v.Value {
+		vals = vals[:0]
+		for _, x := range v.Value {
 			o, err := resolveExpr(ctx, x, v.Paren)
 			_ = err // fuq this error
-			vals[i] = o
+			// null values are dropped from lists
+			if len(o) > 0 {
+				vals = append(vals, o)
+			}
 		}
-		return strings.Join(vals, delim), nil
+		out = strings.Join(vals, delim)
+		if v.Bracket {
+			out = "[" + out + "]"
+		}
+		return out, nil
+	case *ast.MapLit:
+		// maps are not valid CSS, they are only printed by @debug
+		pairs := make([]string, 0, len(v.Value))
+		for _, kv := range v.Value {
+			k, err := resolveExpr(ctx, kv.Key, false)
+			if err != nil {
+				return "", err
+			}
+			val, err := resolveExpr(ctx, kv.Value, false)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, k+": "+val)
+		}
+		return "(" + strings.Join(pairs, ", ") + ")", nil
 	default:
 		panic(fmt.Sprintf("unhandled expr: % #v\n", v))
 	}
@@ -565,12 +1425,137 @@ func simplifyExprs(ctx *Context, exprs []ast.Expr) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		sums = append(sums, s)
+		if len(s) > 0 {
+			sums = append(sums, s)
+		}
 	}
 
 	return strings.Join(sums, " "), nil
 }
 
+// formatColor lowercases hex colors, compressed output uses the
+// shortest of the hex or CSS name ie. #ffffff => #fff, #ff0000 => red
+func (ctx *Context) formatColor(s string) string {
+	hex, named := ast.LookupColorName(s)
+	if !named {
+		if !isHexColor(s) {
+			return s
+		}
+		hex = strings.ToLower(s)
+	}
+	if ctx.style != Compressed {
+		if named {
+			return s
+		}
+		return hex
+	}
+	if len(hex) == 4 {
+		hex = "#" + string([]byte{hex[1], hex[1], hex[2], hex[2], hex[3], hex[3]})
+	}
+	name := ast.LookupColor(hex)
+	if hex[1] == hex[2] && hex[3] == hex[4] && hex[5] == hex[6] {
+		hex = "#" + string([]byte{hex[1], hex[3], hex[5]})
+	}
+	if len(name) < len(hex) {
+		return name
+	}
+	return hex
+}
+
+// formatNumber rounds numbers to the precision of the context,
+// trailing zeros are removed ie. 1.50000 => 1.5, 2.0 => 2. Compressed
+// output also drops the leading zero ie. 0.5 => .5, -0.5 => -.5. Other
+// kinds are returned unchanged.
+func (ctx *Context) formatNumber(lit *ast.BasicLit) string {
+	if lit.Kind != token.INT && lit.Kind != token.FLOAT &&
+		!lit.Kind.IsCSSNum() {
+		return lit.Value
+	}
+	s := lit.Value
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' ||
+		end == 0 && (s[end] == '-' || s[end] == '+')) {
+		end++
+	}
+	f, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return s
+	}
+	pow := math.Pow(10, float64(ctx.precision))
+	f = math.Round(f*pow) / pow
+	if f == 0 {
+		// avoid -0
+		f = 0
+	}
+	num := strconv.FormatFloat(f, 'f', -1, 64)
+	if ctx.style == Compressed {
+		switch {
+		case strings.HasPrefix(num, "0."):
+			num = num[1:]
+		case strings.HasPrefix(num, "-0."):
+			num = "-" + num[2:]
+		}
+	}
+	return num + s[end:]
+}
+
+func isHexColor(s string) bool {
+	if len(s) != 4 && len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, ch := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// dropNil removes the missing values of exprs
+func dropNil(exprs []ast.Expr) []ast.Expr {
+	out := exprs[:0:0]
+	for _, x := range exprs {
+		if x != nil {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// splitImportant removes a trailing !important from exprs, reporting
+// whether it was found
+func splitImportant(exprs []ast.Expr) ([]ast.Expr, bool) {
+	if len(exprs) == 0 {
+		return exprs, false
+	}
+	last := len(exprs) - 1
+	if isImportant(exprs[last]) {
+		return exprs[:last], true
+	}
+	list, ok := exprs[last].(*ast.ListLit)
+	if !ok {
+		return exprs, false
+	}
+	vals, important := splitImportant(list.Value)
+	if !important {
+		return exprs, false
+	}
+	var x ast.Expr
+	if len(vals) == 1 && !list.Comma {
+		x = vals[0]
+	} else {
+		cp := *list
+		cp.Value = vals
+		x = &cp
+	}
+	return append(exprs[:last:last], x), true
+}
+
+func isImportant(x ast.Expr) bool {
+	lit, ok := x.(*ast.BasicLit)
+	return ok && lit.Kind == token.IMPORTANT
+}
+
 func printDecl(ctx *Context, node ast.Node) {
 	// I think... nothing to print we'll see
 }
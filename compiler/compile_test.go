@@ -1,8 +1,16 @@
 package compiler
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/parser"
 	"github.com/wellington/sass/token"
 )
 
@@ -204,6 +212,50 @@ b, c { color: red; }
 	}
 }
 
+func TestSelector_group_multiply(t *testing.T) {
+	in := `.a, .b { .c, .d { w: x; } }
+.a, .b, .c { .d { x: y; } }
+.a, .b { .c, .d, .e { y: z; } }
+`
+	e := `.a .c, .a .d, .b .c, .b .d {
+  w: x; }
+
+.a .d, .b .d, .c .d {
+  x: y; }
+
+.a .c, .a .d, .a .e, .b .c, .b .d, .b .e {
+  y: z; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_whitespace(t *testing.T) {
+	in := `.a   >
+  .b { x: 1; }
+div    p,
+.c
+  .d { x: 2; }
+[title="a   b"]   span { x: 3; }
+.e {
+  .f    ~
+    .g    h { x: 4; }
+}
+`
+	e := `.a > .b {
+  x: 1; }
+
+div p, .c .d {
+  x: 2; }
+
+[title="a   b"] span {
+  x: 3; }
+
+.e .f ~ .g h {
+  x: 4; }
+`
+	runParse(t, in, e)
+}
+
 func TestSelector_many_nests(t *testing.T) {
 	ctx := NewContext()
 
@@ -288,3 +340,907 @@ func TestSelector_comboampersand(t *testing.T) {
 	}
 
 }
+
+func TestSelector_ampersand_suffix(t *testing.T) {
+	in := `.block {
+  &:hover { a: b; }
+  &.active { c: d; }
+  &__el { e: f; }
+  & p { g: h; }
+  p & { i: j; }
+}
+`
+	e := `.block:hover {
+  a: b; }
+
+.block.active {
+  c: d; }
+
+.block__el {
+  e: f; }
+
+.block p {
+  g: h; }
+
+p .block {
+  i: j; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_placeholder(t *testing.T) {
+	in := `%button {
+  color: red;
+}
+.foo {
+  @extend %button;
+  width: 1px;
+}
+`
+	e := `.foo {
+  color: red; }
+
+.foo {
+  width: 1px; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_comma_styles(t *testing.T) {
+	in := `h1, h2,h3 { margin: 0; }
+.a, .b {
+  .c, .d { e: f; }
+}
+p:not(.q, .r), b { g: h; i: j; }
+`
+	spaced := `h1, h2, h3 {
+  margin: 0; }
+
+.a .c, .a .d, .b .c, .b .d {
+  e: f; }
+
+p:not(.q, .r), b {
+  g: h;
+  i: j; }
+`
+	tests := []struct {
+		style Style
+		e     string
+	}{
+		{Nested, spaced},
+		{Compressed, `h1,h2,h3{margin:0}.a .c,.a .d,.b .c,.b .d{e:f}` +
+			`p:not(.q, .r),b{g:h;i:j}`},
+	}
+	for _, test := range tests {
+		ctx := NewContext()
+		ctx.SetStyle(test.style)
+		out, err := ctx.runString("", in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.e {
+			t.Errorf("style %d got:\n%q\nwanted:\n%q", test.style, out, test.e)
+		}
+	}
+
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	sel := `a[title="x, y"], p:not(.q, .r), b`
+	e := `a[title="x, y"],p:not(.q, .r),b`
+	if got := ctx.formatSelector(sel); got != e {
+		t.Errorf("got: %q wanted: %q", got, e)
+	}
+}
+
+func TestSelector_attribute(t *testing.T) {
+	in := `input[ type = "text" ] { a: b; }
+a[href^="http"], a[href$='.pdf'] { c: d; }
+[class*=col] { e: f; }
+[lang~=en] { g: h; }
+div [ lang|=en ] { i: j; }
+a[title="x = y ]"] { k: l; }
+p {
+  &[disabled] { m: n; }
+}
+`
+	e := `input[type="text"] {
+  a: b; }
+
+a[href^="http"], a[href$='.pdf'] {
+  c: d; }
+
+[class*=col] {
+  e: f; }
+
+[lang~=en] {
+  g: h; }
+
+div [lang|=en] {
+  i: j; }
+
+a[title="x = y ]"] {
+  k: l; }
+
+p[disabled] {
+  m: n; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_pseudo_args(t *testing.T) {
+	in := `a:not(.a) { b: c; }
+li:nth-child(odd), li:nth-child(2n+1) { d: e; }
+p:not(:first-child) { f: g; }
+:not(.a, .b) { h: i; }
+div {
+  &:not(.x) { j: k; }
+  span:not([title="x)"]) { l: m; }
+}
+`
+	e := `a:not(.a) {
+  b: c; }
+
+li:nth-child(odd), li:nth-child(2n+1) {
+  d: e; }
+
+p:not(:first-child) {
+  f: g; }
+
+:not(.a, .b) {
+  h: i; }
+
+div:not(.x) {
+  j: k; }
+
+div span:not([title="x)"]) {
+  l: m; }
+`
+	runParse(t, in, e)
+}
+
+// mirrors sass-spec basic/05_empty_levels
+func TestSelector_empty_parents(t *testing.T) {
+	in := `.a { .b { color: red; } }
+div {
+  color: gray;
+  empty {
+    span {
+      color: red;
+    }
+  }
+}
+empty1 {
+  empty2 {
+    div {
+      bloo: blee;
+      empty3 {
+        span {
+          blah: blah;
+        }
+      }
+    }
+  }
+}
+`
+	e := `.a .b {
+  color: red; }
+
+div {
+  color: gray; }
+  div empty span {
+    color: red; }
+
+empty1 empty2 div {
+  bloo: blee; }
+  empty1 empty2 div empty3 span {
+    blah: blah; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_empty_rules(t *testing.T) {
+	in := `a { }
+b { c { } }
+d { e { f: g; } h { } }
+i { j { } k: l; }
+m { n: o; p { q { } } r: s; }
+@media print { t { } u { v { } w: x; } }
+y { z: null; }
+`
+	e := `d e {
+  f: g; }
+
+i {
+  k: l; }
+
+m {
+  n: o;
+  r: s; }
+@media print {
+  u {
+    w: x; } }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_placeholder_unused(t *testing.T) {
+	in := `%unused {
+  color: red;
+  .x { color: blue; }
+}
+div {
+  %nested { a: b; }
+  c: d;
+}
+%both, .real {
+  e: f;
+}
+.late {
+  @extend %used;
+}
+%used {
+  g: h;
+}
+`
+	e := `div {
+  c: d; }
+
+.real {
+  e: f; }
+
+.late {
+  g: h; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	out, err := ctx.runString("", `%unused { a: b; }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > 0 {
+		t.Errorf("got: %q wanted no output", out)
+	}
+}
+
+func TestSelector_placeholder_percent_attr(t *testing.T) {
+	in := `a[b="50%"] { c: d; }
+[data-x="50%"] { e: f; }
+`
+	e := `a[b="50%"] {
+  c: d; }
+
+[data-x="50%"] {
+  e: f; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_class(t *testing.T) {
+	in := `.a {
+  color: red;
+}
+.c .a {
+  color: blue;
+}
+.b {
+  @extend .a;
+}
+.d {
+  @extend .a;
+}
+`
+	e := `.a, .b, .d {
+  color: red; }
+
+.c .a, .c .b, .c .d {
+  color: blue; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_source_order(t *testing.T) {
+	in := `.a { x: y; }
+.b.c, div { @extend .a; }
+`
+	e := `.a, .b.c, div {
+  x: y; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_not_group(t *testing.T) {
+	in := `.x:not(.a, .b) { c: d; }
+.y { @extend .x; }
+`
+	e := `.x:not(.a, .b), .y:not(.a, .b) {
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_nested(t *testing.T) {
+	in := `%p {
+  color: red;
+  .x { color: blue; }
+}
+.q {
+  @extend %p;
+}
+`
+	e := `.q {
+  color: red; }
+  .q .x {
+    color: blue; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_placeholder_backref(t *testing.T) {
+	in := `%btn {
+  color: red;
+  &.active { color: blue; }
+  &:hover { a: b; }
+  .x & { c: d; }
+}
+.q {
+  @extend %btn;
+}
+`
+	e := `.q {
+  color: red; }
+  .active.q {
+    color: blue; }
+  .q:hover {
+    a: b; }
+  .x .q {
+    c: d; }
+`
+	runParse(t, in, e)
+
+	in = `.b {
+  &.active { color: blue; }
+}
+.q {
+  @extend .b;
+}
+`
+	e = `.b.active, .active.q {
+  color: blue; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_extend_optional(t *testing.T) {
+	in := `.b {
+  @extend .missing !optional;
+  color: red;
+}
+`
+	e := `.b {
+  color: red; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	_, err := ctx.run("", `.b { @extend .missing; }`)
+	if err == nil {
+		t.Fatal("expected error for missing @extend target")
+	}
+}
+
+func TestSelector_interp_hyphen(t *testing.T) {
+	in := `$name: home;
+.icon-#{$name} {
+  a: b;
+}
+.a-#{$name}-b, p {
+  c: d;
+}
+`
+	e := `.icon-home {
+  a: b; }
+
+.a-home-b, p {
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_interp_leading(t *testing.T) {
+	in := `$s: div;
+#{$s} {
+  a: b;
+}
+p {
+  #{$s} {
+    c: d;
+  }
+}
+`
+	e := `div {
+  a: b; }
+
+p div {
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestSelector_deep_indent(t *testing.T) {
+	// indention is not limited by the depth of nesting
+	const depth = 32
+	var in, e bytes.Buffer
+	var sels []string
+	for i := 0; i < depth; i++ {
+		fmt.Fprintf(&in, "a%d {\n  x: %d;\n", i, i)
+		sels = append(sels, fmt.Sprintf("a%d", i))
+		lvl := strings.Repeat("  ", i)
+		if i > 0 {
+			e.WriteString("\n")
+		}
+		fmt.Fprintf(&e, "%s%s {\n%s  x: %d; }",
+			lvl, strings.Join(sels, " "), lvl, i)
+	}
+	in.WriteString(strings.Repeat("}\n", depth))
+	e.WriteString("\n")
+	runParse(t, in.String(), e.String())
+}
+
+func TestSelector_indent_tab(t *testing.T) {
+	ctx := NewContext()
+	ctx.SetIndentType(IndentTab)
+	ctx.fset = token.NewFileSet()
+	input := `a {
+  b: c;
+  d { e: f; }
+}
+`
+	out, err := ctx.runString("", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := "a {\n\tb: c; }\n\ta d {\n\t\te: f; }\n"
+	if e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestSelector_indent_type(t *testing.T) {
+	input := `a {
+  b: c;
+  d { e: f; }
+}
+`
+	tests := []struct {
+		typ   IndentType
+		width int
+		e     string
+	}{
+		{IndentSpace, 4, "a {\n    b: c; }\n    a d {\n        e: f; }\n"},
+		{IndentTab, 4, "a {\n\tb: c; }\n\ta d {\n\t\te: f; }\n"},
+		{IndentSpace, 0, "a {\nb: c; }\na d {\ne: f; }\n"},
+	}
+	for _, test := range tests {
+		ctx := NewContext()
+		ctx.SetIndentType(test.typ)
+		ctx.SetIndentWidth(test.width)
+		ctx.fset = token.NewFileSet()
+		out, err := ctx.runString("", input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.e != out {
+			t.Errorf("got:\n%q\nwanted:\n%q", out, test.e)
+		}
+	}
+
+	ctx := NewContext()
+	if err := ctx.SetIndentWidth(-1); err == nil {
+		t.Error("expected error for negative width")
+	}
+}
+
+func TestCompile_crlf(t *testing.T) {
+	lf := `// silent
+/* loud
+   comment */
+$x: 1px;
+.a,
+.b > p {
+  e: 1px
+    $x;
+  f: "q";
+  /* inner
+     comment */
+  g: #{$x}-i;
+}
+`
+	ctx := NewContext()
+	ctx.fset = token.NewFileSet()
+	e, err := ctx.runString("", lf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx = NewContext()
+	ctx.fset = token.NewFileSet()
+	out, err := ctx.runString("", strings.Replace(lf, "\n", "\r\n", -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestCompile_trailing_newline(t *testing.T) {
+	tests := []struct {
+		style Style
+		in    string
+		e     string
+	}{
+		{Nested, "div { a: b; }\n\n\n", "div {\n  a: b; }\n"},
+		{Nested, "div { a: b; }\n/* end */", "div {\n  a: b; }\n\n/* end */\n"},
+		{Nested, `@import "foo.css";`, "@import \"foo.css\";\n"},
+		{Nested, "$x: 1;", ""},
+		{Compressed, "div { a: b; }\n\n", "div{a:b}"},
+		{Compressed, `@import "foo.css";`, "@import \"foo.css\";"},
+	}
+	for _, test := range tests {
+		ctx := NewContext()
+		ctx.SetStyle(test.style)
+		out, err := ctx.runString("", test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != test.e {
+			t.Errorf("style %d got: %q wanted: %q", test.style, out, test.e)
+		}
+	}
+}
+
+func TestImport_hoist(t *testing.T) {
+	in := `div { a: b; }
+@import "foo.css";
+p { c: d; }
+`
+	e := `@import "foo.css";
+div {
+  a: b; }
+
+p {
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestImport_hoist_reuse(t *testing.T) {
+	ctx := NewContext()
+	e := "@import \"foo.css\";\ndiv {\n  a: b; }\n"
+	for i := 0; i < 2; i++ {
+		out, err := ctx.Compile([]byte(`div { a: b; }
+@import "foo.css";
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != e {
+			t.Errorf("compile %d got: %q wanted: %q", i, out, e)
+		}
+	}
+	out, err := ctx.Compile([]byte(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > 0 {
+		t.Errorf("got: %q wanted no output", out)
+	}
+}
+
+func TestImport_css(t *testing.T) {
+	in := `@import "foo.css";
+@import url(foo);
+@import url("bar.css");
+@import "http://fonts.com/a";
+@import "https://fonts.com/b";
+@import "//fonts.com/c";
+@import "foo" screen;
+@import "foo" screen and (orientation: landscape);
+@import "foo" print, screen;
+@import "a.css", url(b.css);
+div { a: b; }
+`
+	e := `@import "foo.css";
+@import url(foo);
+@import url("bar.css");
+@import "http://fonts.com/a";
+@import "https://fonts.com/b";
+@import "//fonts.com/c";
+@import "foo" screen;
+@import "foo" screen and (orientation: landscape);
+@import "foo" print, screen;
+@import "a.css";
+@import url(b.css);
+div {
+  a: b; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	_, err := ctx.runString("", `@import "a.css" print, "b.css";`)
+	if err == nil {
+		t.Error("expected media query error")
+	}
+}
+
+func TestImport_list(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"_a.scss": "a { b: c; }\n",
+		"_b.scss": "@import \"a\", \"c\";\n",
+		"_c.scss": "c { d: e; }\n",
+		"in.scss": "@import \"b\", \"x.css\", \"a\";\nf { g: h; }\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := CompileTo(&buf, filepath.Join(dir, "in.scss")); err != nil {
+		t.Fatal(err)
+	}
+	e := `@import "x.css";
+a {
+  b: c; }
+
+c {
+  d: e; }
+
+a {
+  b: c; }
+
+f {
+  g: h; }
+`
+	if out := buf.String(); out != e {
+		t.Errorf("got:\n%s\nwanted:\n%s", out, e)
+	}
+}
+
+// writes records each call to Write
+type writes [][]byte
+
+func (w *writes) Write(p []byte) (int, error) {
+	*w = append(*w, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func TestCompileTo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compileto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "in.scss")
+	in := `div { a: b; }
+p { c: d; }
+`
+	if err := ioutil.WriteFile(path, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var w writes
+	if err := CompileTo(&w, path); err != nil {
+		t.Fatal(err)
+	}
+	if len(w) < 2 {
+		t.Errorf("output was not streamed, got %d writes", len(w))
+	}
+	e := `div {
+  a: b; }
+
+p {
+  c: d; }
+`
+	if out := string(bytes.Join(w, nil)); out != e {
+		t.Errorf("got:\n%s\nwanted:\n%s", out, e)
+	}
+}
+
+func TestCompileFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compilefiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"_vars.scss": "$color: red !default;\n",
+		"a.scss":     "@import \"vars\";\na { color: $color; }\n",
+		"b.scss":     "$color: blue;\n@import \"vars\";\nb { color: $color; }\n",
+		"bad.scss":   "c { @extend .missing; }\n",
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := filepath.Join(dir, "a.scss")
+	b := filepath.Join(dir, "b.scss")
+	bad := filepath.Join(dir, "bad.scss")
+	missing := filepath.Join(dir, "missing.scss")
+	got := make(map[string]string)
+	ctx := NewContext()
+	err = ctx.CompileFiles([]string{a, bad, missing, b},
+		func(path, css string) {
+			got[path] = css
+		})
+
+	e := map[string]string{
+		a: "a {\n  color: red; }\n",
+		b: "b {\n  color: blue; }\n",
+	}
+	if len(got) != len(e) {
+		t.Errorf("got: %d files wanted: %d", len(got), len(e))
+	}
+	for path, css := range e {
+		if got[path] != css {
+			t.Errorf("%s got:\n%s\nwanted:\n%s", path, got[path], css)
+		}
+	}
+
+	errs, ok := err.(BatchError)
+	if !ok {
+		t.Fatalf("got: %T %v wanted: BatchError", err, err)
+	}
+	if len(errs) != 2 || errs[bad] == nil || errs[missing] == nil {
+		t.Errorf("got: %v wanted errors for %s and %s", errs, bad, missing)
+	}
+
+	// the partial was cached once for both files
+	if n := ctx.env.Imports.Len(); n != 1 {
+		t.Errorf("got: %d cached imports wanted: 1", n)
+	}
+}
+
+func TestCompileDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compiledir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		"_vars.scss":     "$color: red;\n",
+		"main.scss":      "@import \"vars\";\na { color: $color; }\n",
+		"sub/page.scss":  "@import \"part\";\nb { @include p(); }\n",
+		"sub/_part.scss": "@mixin p() { c: d; }\n",
+		"bad.scss":       "c { @extend .missing; }\n",
+		"notes.txt":      "not sass\n",
+	}
+	for name, in := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(in), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(dir, "out")
+	err = CompileDir(src, out)
+	errs, ok := err.(BatchError)
+	if !ok {
+		t.Fatalf("got: %T %v wanted: BatchError", err, err)
+	}
+	if bad := filepath.Join(src, "bad.scss"); len(errs) != 1 || errs[bad] == nil {
+		t.Errorf("got: %v wanted an error for %s", errs, bad)
+	}
+
+	e := map[string]string{
+		"main.css":     "a {\n  color: red; }\n",
+		"sub/page.css": "b {\n  c: d; }\n",
+	}
+	var got []string
+	filepath.Walk(out, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(out, path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	if len(got) != len(e) {
+		t.Errorf("got: %v wanted: %d files", got, len(e))
+	}
+	for name, css := range e {
+		b, err := ioutil.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(b) != css {
+			t.Errorf("%s got:\n%s\nwanted:\n%s", name, b, css)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	ctx := NewContext()
+	ctx.SetMode(parser.ParseComments)
+	in := `$x: 1px;
+/* c */
+@mixin m() { a: $x; }
+div { @include m(); }
+`
+	f, err := ctx.Parse("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := []string{"*ast.GenDecl", "*ast.CommDecl", "*ast.FuncDecl", "*ast.SelDecl"}
+	if len(f.Decls) != len(types) {
+		t.Fatalf("got %d decls wanted: %d", len(f.Decls), len(types))
+	}
+	for i, decl := range f.Decls {
+		if typ := fmt.Sprintf("%T", decl); typ != types[i] {
+			t.Errorf("decl %d got: %s wanted: %s", i, typ, types[i])
+		}
+	}
+	pos := ctx.FileSet().Position(f.Decls[2].Pos())
+	if pos.Line != 3 || pos.Column != 1 {
+		t.Errorf("got position: %s wanted: 3:1", pos)
+	}
+
+	_, err = ctx.Parse("", "div { a: b;")
+	if _, ok := err.(*CompileError); !ok {
+		t.Errorf("got: %v wanted a CompileError", err)
+	}
+}
+
+func TestResolveAssign_error(t *testing.T) {
+	ctx := NewContext()
+	ctx.fset = token.NewFileSet()
+	// calls are evaluated by the parser, one left unresolved is an
+	// error rather than a crash
+	call := &ast.CallExpr{Fun: ast.NewIdent("foo")}
+	lits := resolveAssign(ctx, &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent("$x")},
+		Rhs: []ast.Expr{call},
+	})
+	if lits != nil {
+		t.Errorf("got: %v wanted: nil", lits)
+	}
+	if _, ok := ctx.err.(*CompileError); !ok {
+		t.Fatalf("got: %v wanted a CompileError", ctx.err)
+	}
+	e := "unresolved function call foo"
+	if !strings.Contains(ctx.err.Error(), e) {
+		t.Errorf("got: %s wanted: %s", ctx.err, e)
+	}
+}
+
+func TestSetTrace(t *testing.T) {
+	ctx := NewContext()
+	if ctx.mode != parser.ParseComments {
+		t.Errorf("got mode: %d wanted: %d", ctx.mode, parser.ParseComments)
+	}
+	ctx.SetTrace(true)
+	if ctx.mode != parser.ParseComments|parser.Trace {
+		t.Errorf("trace was not enabled, mode: %d", ctx.mode)
+	}
+	ctx.SetTrace(false)
+	if ctx.mode != parser.ParseComments {
+		t.Errorf("trace was not disabled, mode: %d", ctx.mode)
+	}
+}
@@ -73,3 +73,431 @@ div {
 		t.Fatalf("got:\n%s\nwanted:\n%s", out, e)
 	}
 }
+
+func TestDecl_func_math(t *testing.T) {
+	in := `@function double($n) {
+  @return $n * 2;
+}
+$base: 10px;
+$x: double(2);
+$y: $x + double(1);
+div {
+  a: double(2);
+  b: $base * double(2);
+  c: double(3) + 1;
+  d: 2 + double(double(1));
+  e: $x;
+  f: $y;
+}
+`
+	e := `div {
+  a: 4;
+  b: 40px;
+  c: 7;
+  d: 6;
+  e: 4;
+  f: 6; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_builtin_math(t *testing.T) {
+	in := `div {
+  a: nth(1px 2px, 2) * 2;
+  b: 1 + length(a b c);
+}
+`
+	e := `div {
+  a: 4px;
+  b: 4; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_compass_names(t *testing.T) {
+	// sprite and image helpers are not special in plain Sass
+	in := `.sprite {
+  background: url(sprite.png) no-repeat;
+  width: image-width;
+}
+.inline-image {
+  a: image-url;
+  b: sprite-file;
+}
+`
+	e := `.sprite {
+  background: url(sprite.png) no-repeat;
+  width: image-width; }
+
+.inline-image {
+  a: image-url;
+  b: sprite-file; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_url(t *testing.T) {
+	in := `$p: img;
+$q: "a.png";
+div {
+  a: url(path/to/img.png?v=2#hash);
+  b: url("path/to/img.png?v=2#hash");
+  c: url('a b.png?x=1');
+  d: url(#{$p}/a.png?v=2#top);
+  e: url("#{$p}/a.png?v=2#top");
+  f: url(data:image/png;base64,iVBOR=) no-repeat;
+  g: url($q);
+}`
+	e := `div {
+  a: url(path/to/img.png?v=2#hash);
+  b: url("path/to/img.png?v=2#hash");
+  c: url("a b.png?x=1");
+  d: url(img/a.png?v=2#top);
+  e: url("img/a.png?v=2#top");
+  f: url(data:image/png;base64,iVBOR=) no-repeat;
+  g: url("a.png"); }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_cssfunc(t *testing.T) {
+	in := `$gutter: 10px;
+$name: item;
+div {
+  a: counter(item);
+  b: counters($name, ".");
+  c: attr(href);
+  d: translate(10px, 20px) rotate(45deg);
+  e: calc(100% - #{$gutter});
+}`
+	e := `div {
+  a: counter(item);
+  b: counters(item, ".");
+  c: attr(href);
+  d: translate(10px, 20px) rotate(45deg);
+  e: calc(100% - 10px); }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_calc(t *testing.T) {
+	in := `$x: 20px;
+div {
+  a: calc(100% - 20px);
+  b: calc(1px + 2px);
+  c: calc(100% - #{$x});
+  d: calc((100% - #{$x}) / 3);
+  e: calc(100% - #{$x * 2});
+  f: calc(100% - $x);
+}`
+	e := `div {
+  a: calc(100% - 20px);
+  b: calc(1px + 2px);
+  c: calc(100% - 20px);
+  d: calc((100% - 20px) / 3);
+  e: calc(100% - 40px);
+  f: calc(100% - $x); }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_important(t *testing.T) {
+	in := `div {
+  a: red;
+  b: red !important;
+  c: red  !  important;
+  d: 1px + 2px !IMPORTANT;
+  e: a, b !important;
+}`
+	e := `div {
+  a: red;
+  b: red !important;
+  c: red !important;
+  d: 3px !important;
+  e: a, b !important; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_important_unspaced(t *testing.T) {
+	in := `div {
+  a: 1px!important;
+  b: 1px 2px!important;
+  c: red!important;
+}`
+	e := `div {
+  a: 1px !important;
+  b: 1px 2px !important;
+  c: red !important; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ce := `div{a:1px!important;b:1px 2px!important;c:red!important}`
+	if ce != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, ce)
+	}
+
+	// compressed output must parse back to the same declarations
+	runParse(t, out, e)
+}
+
+func TestDecl_comma_list(t *testing.T) {
+	in := `div {
+  font-family: "Helvetica Neue", Arial, sans-serif;
+  font: italic bold 12px/30px Georgia, serif;
+  transition: opacity 0.3s ease-in, transform 1s linear;
+}`
+	e := `div {
+  font-family: "Helvetica Neue", Arial, sans-serif;
+  font: italic bold 12px/30px Georgia, serif;
+  transition: opacity 0.3s ease-in, transform 1s linear; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_quoted_list(t *testing.T) {
+	in := `div {
+  a: "x";
+  b: "x" "y";
+  c: a "y";
+  d: "x" b;
+  e: "x" b "z";
+  f: a "y" c;
+}`
+	e := `div {
+  a: "x";
+  b: "x" "y";
+  c: a "y";
+  d: "x" b;
+  e: "x" b "z";
+  f: a "y" c; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_single_quotes(t *testing.T) {
+	in := `$s: 'say "hi"';
+div {
+  content: 'it\'s';
+  b: "a\"b";
+  c: 'x';
+  d: $s;
+  e: 'it\'s "x"';
+}`
+	e := `div {
+  content: "it's";
+  b: 'a"b';
+  c: "x";
+  d: 'say "hi"';
+  e: "it's \"x\""; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_string_escapes(t *testing.T) {
+	in := `div {
+  a: "\2014";
+  b: "a\\'b";
+  c: "a\\";
+  d: '\'' "\"";
+  e: "\f101 #{1}";
+}`
+	e := `div {
+  a: "\2014";
+  b: "a\\'b";
+  c: "a\\";
+  d: "'" '"';
+  e: "\f101 1"; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_hex_colors(t *testing.T) {
+	in := `div {
+  a: #FFFFFF;
+  b: #AbCdEf;
+  c: #FFF #112233;
+}`
+	e := `div {
+  a: #ffffff;
+  b: #abcdef;
+  c: #fff #112233; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `div{a:#fff;b:#abcdef;c:#fff #123}`
+	if e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestDecl_color_names(t *testing.T) {
+	in := `div {
+  a: red;
+  b: lighten(red, 10%);
+  c: red + #010101;
+  d: "red";
+  e: 1px solid orange;
+  f: #FF0000 white;
+}`
+	e := `div {
+  a: red;
+  b: #ff3333;
+  c: #ff0101;
+  d: "red";
+  e: 1px solid orange;
+  f: #ff0000 white; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `div{a:red;b:#f33;c:#ff0101;d:"red";e:1px solid orange;f:red #fff}`
+	if e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestDecl_var_comma_list(t *testing.T) {
+	in := `$list: 1px, 2px;
+$font: Helvetica, Arial, sans-serif;
+$mixed: 1px 2px, 3px 4px;
+div {
+  a: $list;
+  b: $font;
+  c: $mixed;
+}`
+	e := `div {
+  a: 1px, 2px;
+  b: Helvetica, Arial, sans-serif;
+  c: 1px 2px, 3px 4px; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_null(t *testing.T) {
+	in := `$n: null;
+@function f($x) {
+  @if $x {
+    @return yes;
+  } @else {
+    @return no;
+  }
+}
+div {
+  color: null;
+  a: $n;
+  b: a null b;
+  c: f(null);
+  d: type-of(null);
+}
+p {
+  color: null;
+}
+@if $n {
+  span {
+    a: b;
+  }
+}
+`
+	e := `div {
+  b: a b;
+  c: no;
+  d: null; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_default(t *testing.T) {
+	in := `$a: 1px;
+$a: 2px !default;
+$b: 3px !default;
+$c: null;
+$c: 4px !default;
+$list: a b;
+$list: c d !default;
+$e: x y !default;
+div {
+  a: $a;
+  b: $b;
+  c: $c;
+  d: $list;
+  e: $e;
+}`
+	e := `div {
+  a: 1px;
+  b: 3px;
+  c: 4px;
+  d: a b;
+  e: x y; }
+`
+	runParse(t, in, e)
+}
+
+func TestDecl_bool(t *testing.T) {
+	in := `$x: false;
+@function f($v) {
+  @if $v {
+    @return yes;
+  } @else {
+    @return no;
+  }
+}
+@mixin m($v: false) {
+  @if $v {
+    m: yes;
+  } @else {
+    m: no;
+  }
+}
+@if $x {
+  a {
+    b: x;
+  }
+} @else if 1 == 1 {
+  a {
+    b: eq;
+  }
+}
+div {
+  a: true;
+  b: $x;
+  c: 1 == 2;
+  d: type-of(true);
+  e: f(false);
+  f: f(true);
+  @include m;
+  @include m(true);
+}
+`
+	e := `a {
+  b: eq; }
+
+div {
+  a: true;
+  b: false;
+  c: false;
+  d: bool;
+  e: no;
+  f: yes;
+  m: no;
+  m: yes; }
+`
+	runParse(t, in, e)
+}
@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/wellington/sass/token"
@@ -57,3 +58,536 @@ func TestDirective_each(t *testing.T) {
 		t.Fatalf("got:\n%s\nwanted:\n%s", out, e)
 	}
 }
+
+func TestDirective_else_if_chain(t *testing.T) {
+	chain := `div {
+  @if $x == 1 { a: one; }
+  @else if $x == 2 { a: two; }
+  @elseif $x == 3 { a: three; }
+  @else { a: other; }
+}
+`
+	tests := []struct {
+		x string
+		e string
+	}{
+		{"1", "one"},
+		{"2", "two"},
+		{"3", "three"},
+		{"4", "other"},
+	}
+	for _, test := range tests {
+		in := "$x: " + test.x + ";\n" + chain
+		e := "div {\n  a: " + test.e + "; }\n"
+		runParse(t, in, e)
+	}
+}
+
+func TestDirective_else_orphan(t *testing.T) {
+	tests := []struct {
+		in, e string
+	}{
+		{"a { b: c; }\n@else { d { e: f; } }\n", "in.scss:2:1: @else must follow @if"},
+		{"a {\n  b: c;\n  @else if $x { e: f; }\n}\n", "in.scss:3:3: @else must follow @if"},
+	}
+	for _, test := range tests {
+		ctx := NewContext()
+		_, err := ctx.runString("in.scss", test.in)
+		if err == nil {
+			t.Errorf("%q expected error", test.in)
+			continue
+		}
+		if err.Error() != test.e {
+			t.Errorf("got: %s wanted: %s", err, test.e)
+		}
+	}
+}
+
+func TestDirective_media_empty(t *testing.T) {
+	in := `@media print {}
+div {
+  a: b;
+  @media screen { }
+}
+@media screen {
+}
+`
+	e := `div {
+  a: b; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_media_root(t *testing.T) {
+	in := `@media print {
+  div { a: b; }
+}
+p { c: d; }
+`
+	e := `@media print {
+  div {
+    a: b; } }
+
+p {
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_media_bubble(t *testing.T) {
+	in := `.a {
+  @media screen and (min-width: 600px) { c: d; }
+  e: f;
+}
+.b {
+  .c {
+    @media print { g: h; }
+  }
+}
+`
+	e := `.a {
+  e: f; }
+@media screen and (min-width: 600px) {
+  .a {
+    c: d; } }
+@media print {
+  .b .c {
+    g: h; } }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_media_rules(t *testing.T) {
+	in := `@media print {
+  a { x: y; }
+  b { z: w; }
+}
+p { q: r; }
+`
+	e := `@media print {
+  a {
+    x: y; }
+  b {
+    z: w; } }
+
+p {
+  q: r; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_atrule(t *testing.T) {
+	in := `@supports (display: grid) {
+  div { a: b; }
+}
+@font-face {
+  font-family: x;
+  src: url(a.woff);
+}
+.a {
+  @supports not (display: grid) { c: d; }
+}
+`
+	e := `@supports (display: grid) {
+  div {
+    a: b; } }
+
+@font-face {
+  font-family: x;
+  src: url(a.woff); }
+@supports not (display: grid) {
+  .a {
+    c: d; } }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_keyframes(t *testing.T) {
+	in := `.a {
+  @keyframes spin {
+    from { a: b; }
+    50% { c: d; }
+    0%, 100% { e: f; }
+  }
+}
+`
+	e := `@keyframes spin {
+  from {
+    a: b; }
+  50% {
+    c: d; }
+  0%, 100% {
+    e: f; } }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_atroot(t *testing.T) {
+	in := `@mixin m() {
+  @at-root .mixed { a: b; }
+}
+.a {
+  b: c;
+  .d {
+    e: f;
+    .g {
+      @at-root .h {
+        i: j;
+        .k { l: m; }
+      }
+      n: o;
+    }
+  }
+}
+.p {
+  q: r;
+  @at-root {
+    .s { t: u; }
+    .v { w: x; }
+  }
+}
+.y { .z { @include m; } }
+`
+	e := `.a {
+  b: c; }
+  .a .d {
+    e: f; }
+    .a .d .g {
+      n: o; }
+
+.h {
+  i: j; }
+  .h .k {
+    l: m; }
+
+.p {
+  q: r; }
+
+.s {
+  t: u; }
+
+.v {
+  w: x; }
+
+.mixed {
+  a: b; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_atroot_parent(t *testing.T) {
+	in := `.a {
+  @at-root &-x { b: c; }
+  .d {
+    @at-root & .e { f: g; }
+  }
+}
+.h, .i {
+  @at-root {
+    &-j { k: l; }
+    .m { n: o; }
+  }
+}
+`
+	e := `.a-x {
+  b: c; }
+
+.a .d .e {
+  f: g; }
+
+.h-j, .i-j {
+  k: l; }
+
+.m {
+  n: o; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_atroot_media(t *testing.T) {
+	in := `.a {
+  @media print {
+    b: c;
+    @at-root (without: media) {
+      .d { e: f; }
+    }
+    @at-root {
+      .g { h: i; }
+    }
+  }
+}
+`
+	e := `@media print {
+  .a {
+    b: c; } }
+
+.a .d {
+  e: f; }
+@media print {
+  .g {
+    h: i; } }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_each_map(t *testing.T) {
+	in := `$palette: (primary: #336699, danger: #cc3333);
+div {
+  @each $name, $color in $palette {
+    border: $name lighten($color, 10%);
+  }
+}
+`
+	e := `div {
+  border: primary #4080bf;
+  border: danger #d65c5c; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_mixin_default_map(t *testing.T) {
+	in := `@mixin m($config: (a: 1, b: 2), $list: (1px 2px)) {
+  @each $k, $v in $config {
+    c: $k $v;
+  }
+  margin: $list;
+}
+div { @include m; }
+`
+	e := `div {
+  c: a 1;
+  c: b 2;
+  margin: 1px 2px; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_debug_warn(t *testing.T) {
+	ctx := NewContext()
+	var log bytes.Buffer
+	ctx.SetLogOutput(&log)
+	in := `$x: 1px;
+@debug $x;
+div {
+  @warn "careful #{$x}";
+  a: b;
+  @debug 1px + 2px;
+}
+`
+	out, err := ctx.runString("in.scss", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := "div {\n  a: b; }\n"; e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+
+	e := `in.scss:2 DEBUG: 1px
+WARNING: careful 1px
+         on line 4 of in.scss
+
+in.scss:6 DEBUG: 3px
+`
+	if e != log.String() {
+		t.Errorf("got:\n%q\nwanted:\n%q", log.String(), e)
+	}
+}
+
+func TestDirective_keywords(t *testing.T) {
+	ctx := NewContext()
+	var log bytes.Buffer
+	ctx.SetLogOutput(&log)
+	in := `@mixin m($args...) { @debug keywords($args); }
+div {
+  @include m(1px, $a: 2, $b: red);
+  @include m($c: 3px);
+  @include m(1px, 2px);
+  a: b;
+}
+`
+	out, err := ctx.runString("in.scss", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := "div {\n  a: b; }\n"; e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+
+	e := `in.scss:1 DEBUG: (a: 2, b: red)
+in.scss:1 DEBUG: (c: 3px)
+in.scss:1 DEBUG: ()
+`
+	if e != log.String() {
+		t.Errorf("got:\n%q\nwanted:\n%q", log.String(), e)
+	}
+}
+
+func TestDirective_keywords_map(t *testing.T) {
+	in := `@function f($args...) {
+  @return map-get(keywords($args), y);
+}
+@mixin m($args...) {
+  $k: keywords($args);
+  a: map-get($k, x);
+  b: inspect(keywords($args));
+}
+div {
+  c: f($x: 1, $y: 2);
+  @include m($x: 3, $y: 4);
+}
+`
+	e := `div {
+  c: 2;
+  a: 3;
+  b: (x: 3, y: 4); }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_error(t *testing.T) {
+	ctx := NewContext()
+	in := `$x: 1px;
+div {
+  a: b;
+  @error "bad input: #{$x}";
+}
+`
+	_, err := ctx.runString("in.scss", in)
+	cerr, ok := err.(*CompileError)
+	if !ok {
+		t.Fatalf("expected *CompileError got: %T %v", err, err)
+	}
+	if e := "bad input: 1px"; cerr.Msg != e {
+		t.Errorf("got: %s wanted: %s", cerr.Msg, e)
+	}
+	if e := "in.scss:4:3: bad input: 1px"; err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+}
+
+func TestDirective_error_mixin(t *testing.T) {
+	ctx := NewContext()
+	in := `@mixin m($x) {
+  @error "bad #{$x}";
+}
+div {
+  @include m(2px);
+}
+`
+	_, err := ctx.runString("in.scss", in)
+	if _, ok := err.(*CompileError); !ok {
+		t.Fatalf("expected *CompileError got: %T %v", err, err)
+	}
+	if e := "in.scss:2:3: bad 2px"; err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+}
+
+func TestDirective_css_atrule(t *testing.T) {
+	in := `@charset "UTF-8";
+@namespace svg url(http://www.w3.org/2000/svg);
+@page :first { margin: 0; }
+`
+	e := `@charset "UTF-8";
+@namespace svg url(http://www.w3.org/2000/svg);
+
+@page :first {
+  margin: 0; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_content(t *testing.T) {
+	in := `$c: blue;
+@mixin inner() {
+  i: 1;
+  @content;
+}
+@mixin outer() {
+  @include inner() {
+    o: 2;
+    @content;
+  }
+}
+div {
+  @include outer() { color: $c; }
+}
+`
+	e := `div {
+  i: 1;
+  o: 2;
+  color: blue; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_content_no_parens(t *testing.T) {
+	in := `@mixin box {
+  a {
+    @content;
+  }
+}
+@mixin wrap() {
+  b: 1;
+  @content;
+}
+@include box { c: d; }
+div {
+  @include wrap { e: f; }
+}
+`
+	e := `a {
+  c: d; }
+
+div {
+  b: 1;
+  e: f; }
+`
+	runParse(t, in, e)
+}
+
+func TestDirective_content_exists(t *testing.T) {
+	in := `@mixin m() {
+  a: content-exists();
+  @if content-exists() {
+    @content;
+  }
+}
+div {
+  @include m() { c: d; }
+}
+p {
+  @include m();
+}
+`
+	e := `div {
+  a: true;
+  c: d; }
+
+p {
+  a: false; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	_, err := ctx.runString("", "div { a: content-exists(); }")
+	if err == nil {
+		t.Fatal("expected error outside of a mixin")
+	}
+}
+
+func TestDirective_mixin_call(t *testing.T) {
+	in := `@mixin m($x) {
+  a: darken($x, 10%);
+}
+div { @include m(red); }
+p { @include m(blue); }
+`
+	e := `div {
+  a: #cc0000; }
+
+p {
+  a: #0000cc; }
+`
+	runParse(t, in, e)
+}
@@ -0,0 +1,83 @@
+package compiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wellington/sass/scanner"
+	"github.com/wellington/sass/token"
+)
+
+// CompileError describes a problem found while compiling and where in
+// the source it was found.
+type CompileError struct {
+	Filename string
+	Line     int
+	Column   int
+	Msg      string
+}
+
+// Error implements the error interface. The position is formatted
+// as filename:line:column, filename is omitted when unknown.
+func (e *CompileError) Error() string {
+	pos := token.Position{
+		Filename: e.Filename,
+		Line:     e.Line,
+		Column:   e.Column,
+	}
+	if !pos.IsValid() {
+		return e.Msg
+	}
+	return pos.String() + ": " + e.Msg
+}
+
+func newCompileError(pos token.Position, msg string) *CompileError {
+	return &CompileError{
+		Filename: pos.Filename,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Msg:      msg,
+	}
+}
+
+// errorf reports msg at the position pos
+func (ctx *Context) errorf(pos token.Pos, format string, args ...interface{}) error {
+	return newCompileError(ctx.fset.Position(pos),
+		fmt.Sprintf(format, args...))
+}
+
+// BatchError holds the error of each file that failed to compile in
+// CompileFiles and CompileDir by path
+type BatchError map[string]error
+
+// Error implements the error interface, the errors are listed one per
+// line sorted by path
+func (e BatchError) Error() string {
+	paths := make([]string, 0, len(e))
+	for path := range e {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	msgs := make([]string, len(paths))
+	for i, path := range paths {
+		msgs[i] = path + ": " + e[path].Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// toCompileError converts errors reported by the parser to a
+// CompileError. Only the first of multiple errors is kept.
+func toCompileError(err error) error {
+	switch v := err.(type) {
+	case scanner.ErrorList:
+		if len(v) > 0 {
+			return newCompileError(v[0].Pos, v[0].Msg)
+		}
+	case *scanner.Error:
+		return newCompileError(v.Pos, v.Msg)
+	case scanner.Error:
+		return newCompileError(v.Pos, v.Msg)
+	}
+	return err
+}
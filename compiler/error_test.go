@@ -0,0 +1,43 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/token"
+)
+
+func TestCompileError_parse(t *testing.T) {
+	ctx := NewContext()
+	ctx.fset = token.NewFileSet()
+	in := `div {
+  a: math.clamp(0px, 5em, 3px);
+}`
+	_, err := ctx.runString("in.scss", in)
+	cerr, ok := err.(*CompileError)
+	if !ok {
+		t.Fatalf("expected *CompileError got: %T %v", err, err)
+	}
+	if e := "in.scss"; cerr.Filename != e {
+		t.Errorf("got: %s wanted: %s", cerr.Filename, e)
+	}
+	if cerr.Line != 2 || cerr.Column != 16 {
+		t.Errorf("got: %d:%d wanted: 2:16", cerr.Line, cerr.Column)
+	}
+	e := "in.scss:2:16: $min: 0px and $number: 5em have incompatible units"
+	if err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+}
+
+func TestCompileError_strict(t *testing.T) {
+	_, err := runStrict(t, `div {
+  width: 2px * 2px;
+}`)
+	cerr, ok := err.(*CompileError)
+	if !ok {
+		t.Fatalf("expected *CompileError got: %T %v", err, err)
+	}
+	if cerr.Line != 2 || cerr.Column != 14 {
+		t.Errorf("got: %d:%d wanted: 2:14", cerr.Line, cerr.Column)
+	}
+}
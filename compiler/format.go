@@ -0,0 +1,495 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Format pretty-prints Sass source. Blocks are indented by two spaces,
+// declarations are written one per line with a single space after the
+// colon, selector lists are separated by ", " and binary operators are
+// surrounded by single spaces. Declaration order, comments and single
+// blank lines are preserved. Formatting the output of Format again
+// does not change it.
+//
+// Format works on the source rather than the AST. The parser resolves
+// variables, expands @include, evaluates @if and @each and keeps no
+// comments inside of rules, so printing the AST would not give back
+// the Sass that was written. Only whitespace is changed and never
+// inside of strings, comments, url() or interpolation, the compiled
+// CSS of the formatted source is the same.
+func Format(src []byte) ([]byte, error) {
+	items, err := fmtSplit(src)
+	if err != nil {
+		return nil, err
+	}
+	return fmtPrint(items), nil
+}
+
+type fmtKind int
+
+const (
+	fmtHeader  fmtKind = iota // selector or at-rule opening a block
+	fmtStmt                   // declaration or at-rule ended by ;
+	fmtClose                  // end of a block
+	fmtComment                // comment on its own line
+)
+
+type fmtItem struct {
+	kind  fmtKind
+	text  string
+	blank bool   // preceded by a blank line
+	trail string // comment following on the same line
+}
+
+// fmtSplit breaks src into headers, statements, block ends and
+// comments. Strings, parens and interpolation are copied as is.
+func fmtSplit(src []byte) ([]fmtItem, error) {
+	var items []fmtItem
+	var cur bytes.Buffer
+	var depth, parens, interp int
+	// newlines since the last item, lead is the count when the
+	// current statement started
+	var pending, lead int
+
+	lineOf := func(i int) int {
+		return bytes.Count(src[:i], []byte("\n")) + 1
+	}
+	add := func(kind fmtKind, text string, newlines int) {
+		items = append(items, fmtItem{
+			kind:  kind,
+			text:  text,
+			blank: newlines > 1,
+		})
+		pending = 0
+	}
+	flush := func(kind fmtKind) {
+		text := fmtCollapse(cur.String())
+		cur.Reset()
+		switch {
+		case kind == fmtHeader && !strings.HasPrefix(text, "@") &&
+			!strings.HasSuffix(text, ":"):
+			text = fmtSelector(text)
+		case kind == fmtStmt && !strings.HasPrefix(text, "@"):
+			text = fmtDecl(text)
+		default:
+			text = fmtDirective(text)
+		}
+		add(kind, text, lead)
+	}
+	// comments directly following an item on the same line stay on
+	// that line
+	comment := func(text string) {
+		if n := len(items); n > 0 && pending == 0 &&
+			items[n-1].kind != fmtComment && len(items[n-1].trail) == 0 {
+			items[n-1].trail = text
+			return
+		}
+		add(fmtComment, text, pending)
+	}
+	write := func(s string) {
+		if cur.Len() == 0 {
+			lead = pending
+		}
+		cur.WriteString(s)
+	}
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		var next byte
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+		switch {
+		case c == '"' || c == '\'':
+			end, err := fmtString(src, i)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineOf(i), err)
+			}
+			write(string(src[i : end+1]))
+			i = end
+		case c == '/' && next == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: comment not terminated",
+					lineOf(i))
+			}
+			end += i + 4
+			text := string(src[i:end])
+			if cur.Len() > 0 {
+				write(text)
+			} else {
+				comment(fmtTrimLines(text))
+			}
+			i = end - 1
+		case c == '/' && next == '/' && parens == 0:
+			end := bytes.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i
+			}
+			text := strings.TrimRight(string(src[i:end]), " \t\r")
+			if cur.Len() > 0 {
+				// a comment inside of a statement is moved
+				// before it
+				add(fmtComment, text, lead)
+				lead = 0
+			} else {
+				comment(text)
+			}
+			i = end - 1
+		case c == '#' && next == '{':
+			write("#{")
+			interp++
+			i++
+		case c == '}' && interp > 0:
+			write("}")
+			interp--
+		case c == '(':
+			write("(")
+			parens++
+		case c == ')':
+			write(")")
+			if parens > 0 {
+				parens--
+			}
+		case c == '{' && parens == 0:
+			flush(fmtHeader)
+			depth++
+		case c == ';' && parens == 0:
+			if cur.Len() > 0 {
+				flush(fmtStmt)
+			}
+		case c == '}':
+			if cur.Len() > 0 {
+				flush(fmtStmt)
+			}
+			if depth == 0 {
+				return nil, fmt.Errorf("line %d: unexpected }", lineOf(i))
+			}
+			depth--
+			add(fmtClose, "}", 0)
+		case c == '\n':
+			if cur.Len() == 0 {
+				pending++
+			} else {
+				cur.WriteByte(' ')
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			if cur.Len() > 0 {
+				cur.WriteByte(' ')
+			}
+		default:
+			write(string(c))
+		}
+	}
+	if cur.Len() > 0 {
+		flush(fmtStmt)
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("line %d: missing }", lineOf(len(src)))
+	}
+	return items, nil
+}
+
+// fmtString returns the index of the quote closing the string
+// starting at i
+func fmtString(src []byte, i int) (int, error) {
+	quote := src[i]
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '\n':
+			return 0, fmt.Errorf("string not terminated")
+		case quote:
+			return j, nil
+		}
+	}
+	return 0, fmt.Errorf("string not terminated")
+}
+
+// fmtTrimLines removes trailing whitespace from each line of s
+func fmtTrimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fmtCollapse replaces runs of whitespace outside of strings with a
+// single space
+func fmtCollapse(s string) string {
+	var buf bytes.Buffer
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(s) {
+				buf.WriteByte(c)
+				i++
+				c = s[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ' ':
+			if i+1 < len(s) && s[i+1] == ' ' {
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// fmtScan calls fn with each byte of s outside of strings, parens,
+// brackets and interpolation. Other bytes are copied to the result,
+// fn is responsible for writing the bytes it is called with.
+func fmtScan(s string, fn func(buf *bytes.Buffer, c byte)) string {
+	var buf bytes.Buffer
+	var quote byte
+	var depth int
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(s) {
+				buf.WriteByte(c)
+				i++
+				c = s[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			buf.WriteString("#{")
+			i++
+			continue
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0:
+			fn(&buf, c)
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// fmtSelector spaces commas and combinators in a selector ie.
+// a>b,c => a > b, c
+func fmtSelector(s string) string {
+	var skip bool
+	s = fmtScan(s, func(buf *bytes.Buffer, c byte) {
+		switch c {
+		case ' ':
+			if skip {
+				return
+			}
+		case ',', '>', '+', '~':
+			b := bytes.TrimRight(buf.Bytes(), " ")
+			buf.Truncate(len(b))
+			if c != ',' {
+				buf.WriteByte(' ')
+			}
+			buf.WriteByte(c)
+			buf.WriteByte(' ')
+			skip = true
+			return
+		}
+		skip = false
+		buf.WriteByte(c)
+	})
+	return strings.TrimSpace(s)
+}
+
+// fmtDecl writes a single space after the colon of a declaration
+func fmtDecl(s string) string {
+	var name string
+	var found bool
+	rest := fmtScan(s, func(buf *bytes.Buffer, c byte) {
+		if c == ':' && !found {
+			found = true
+			name = buf.String()
+			buf.Reset()
+			return
+		}
+		buf.WriteByte(c)
+	})
+	if !found {
+		return s
+	}
+	name = strings.TrimSpace(name)
+	rest = strings.TrimSpace(rest)
+	if len(rest) == 0 {
+		return name + ":"
+	}
+	return name + ": " + fmtOps(rest)
+}
+
+// fmtDirective spaces the operators in the expression of @if, @else if,
+// @while and @return
+func fmtDirective(s string) string {
+	for _, kw := range []string{"@if ", "@else if ", "@while ", "@return "} {
+		if strings.HasPrefix(s, kw) {
+			return kw + fmtOps(s[len(kw):])
+		}
+	}
+	return s
+}
+
+// fmtOps writes a single space around the binary operators of the
+// expression s ie. $a+$b => $a + $b. + and * are only spaced between
+// numbers, variables and parens, U+0025 and #{$a}+b are not
+// arithmetic. - and / are left as written, they are also found in
+// identifiers and shorthands like font: 12px/1.5.
+func fmtOps(s string) string {
+	var buf bytes.Buffer
+	var quote byte
+	var interp int
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && i+1 < len(s) {
+				buf.WriteByte(c)
+				i++
+				c = s[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && i+1 < len(s) && s[i+1] == '{':
+			interp++
+		case c == '}' && interp > 0:
+			interp--
+		case interp > 0:
+		case strings.HasPrefix(s[i:], "url("):
+			if end := strings.IndexByte(s[i:], ')'); end > 0 {
+				buf.WriteString(s[i : i+end+1])
+				i += end
+				continue
+			}
+		default:
+			op := fmtOp(s[i:])
+			if len(op) == 0 {
+				break
+			}
+			left := bytes.TrimRight(buf.Bytes(), " ")
+			right := s[i+len(op):]
+			spaced := len(left) < buf.Len()
+			if !fmtBinary(op, left, strings.TrimLeft(right, " "), spaced &&
+				!strings.HasPrefix(right, " ")) {
+				break
+			}
+			buf.Truncate(len(left))
+			buf.WriteString(" " + op + " ")
+			i += len(op) - 1
+			if strings.HasPrefix(right, " ") {
+				i++
+			}
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// fmtOp returns the operator s starts with
+func fmtOp(s string) string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "+", "*"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// fmtBinary reports whether op between left and right is a binary
+// operator. unary is set when op is only followed by its operand,
+// 1 +2 is a list in Sass.
+func fmtBinary(op string, left []byte, right string, unary bool) bool {
+	if len(left) == 0 || len(right) == 0 {
+		return false
+	}
+	last := left[len(left)-1]
+	if last == '}' || last == '(' || last == ',' || right[0] == '#' {
+		return false
+	}
+	if op != "+" && op != "*" {
+		return true
+	}
+	if unary && op == "+" {
+		return false
+	}
+	word := left[bytes.LastIndexAny(left, " (,")+1:]
+	return (last == ')' || fmtOperand(string(word))) &&
+		(right[0] == '(' || fmtOperand(right))
+}
+
+// fmtOperand reports whether s starts with a variable or a number
+func fmtOperand(s string) bool {
+	if strings.HasPrefix(s, "-") {
+		s = s[1:]
+	}
+	if strings.HasPrefix(s, ".") {
+		s = s[1:]
+	}
+	return len(s) > 0 && (s[0] == '$' || '0' <= s[0] && s[0] <= '9')
+}
+
+// fmtPrint writes items indented by the depth of their block
+func fmtPrint(items []fmtItem) []byte {
+	var buf bytes.Buffer
+	var depth int
+	var prev *fmtItem
+	for i := range items {
+		item := &items[i]
+		if item.kind == fmtClose {
+			depth--
+		}
+		// @else continues the block closed before it
+		if prev != nil && prev.kind == fmtClose && len(prev.trail) == 0 &&
+			item.kind == fmtHeader && !item.blank &&
+			strings.HasPrefix(item.text, "@else") {
+			buf.Truncate(buf.Len() - 1)
+			buf.WriteString(" " + item.text + " {")
+		} else {
+			// blank lines are not kept at the start or end of
+			// a block
+			if item.blank && prev != nil && prev.kind != fmtHeader &&
+				item.kind != fmtClose {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(strings.Repeat("  ", depth))
+			switch item.kind {
+			case fmtHeader:
+				buf.WriteString(item.text + " {")
+			case fmtStmt:
+				buf.WriteString(item.text + ";")
+			default:
+				buf.WriteString(item.text)
+			}
+		}
+		if len(item.trail) > 0 {
+			buf.WriteString(" " + item.trail)
+		}
+		buf.WriteByte('\n')
+		if item.kind == fmtHeader {
+			depth++
+		}
+		prev = item
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,145 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/token"
+)
+
+func TestFormat(t *testing.T) {
+	in := `// header
+$x:   1px   !default;
+
+
+@mixin m($a, $b: 2){  width:$a;height : $b }
+h1,h2 ,h3>a{margin:0;padding :  0 1px;
+  a:hover{color:red;} // trailing
+  /* block
+     comment */
+  b { c: url(http://x.com/a;b.png) }
+  font: {
+    family: "Helvetica   Neue" ;
+  }
+}
+@if $x==1px{a{b:c}}
+@else{g{h:i}}
+.a { @include m(1px, 2px); content: "a; b { }"; q: #{$x}+1; }
+@function f($n) { @return $n*2+1; }
+.b { w: $x+$x*2; x: ($x+1)*2; y: 1 +2; u: U+0025; f: 12px/1.5 "a+b"; }
+`
+	e := `// header
+$x: 1px !default;
+
+@mixin m($a, $b: 2) {
+  width: $a;
+  height: $b;
+}
+h1, h2, h3 > a {
+  margin: 0;
+  padding: 0 1px;
+  a:hover {
+    color: red;
+  } // trailing
+  /* block
+     comment */
+  b {
+    c: url(http://x.com/a;b.png);
+  }
+  font: {
+    family: "Helvetica   Neue";
+  }
+}
+@if $x == 1px {
+  a {
+    b: c;
+  }
+} @else {
+  g {
+    h: i;
+  }
+}
+.a {
+  @include m(1px, 2px);
+  content: "a; b { }";
+  q: #{$x}+1;
+}
+@function f($n) {
+  @return $n * 2 + 1;
+}
+.b {
+  w: $x + $x * 2;
+  x: ($x + 1) * 2;
+  y: 1 +2;
+  u: U+0025;
+  f: 12px/1.5 "a+b";
+}
+`
+	out, err := Format([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != e {
+		t.Fatalf("got:\n%s\nwanted:\n%s", out, e)
+	}
+
+	again, err := Format(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(out) {
+		t.Errorf("not idempotent got:\n%s\nwanted:\n%s", again, out)
+	}
+}
+
+func TestFormat_lossless(t *testing.T) {
+	ins := []string{
+		`$a:1px;$b :2px;
+div{w:$a+$b;h:($a+1)*2;@if $a==1px{c:d}@else{e:f}}`,
+		`@function double($n){@return $n*2}
+a>b , c~d{w:double(1px)+1px;font:12px/1.5 serif;content:"x+y"}`,
+		`@mixin m($x){w:$x*3}
+p{@include m(2px);q:#{1+1}+1}`,
+	}
+	for _, in := range ins {
+		out, err := Format([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := NewContext()
+		ctx.fset = token.NewFileSet()
+		e, err := ctx.runString("", in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx = NewContext()
+		ctx.fset = token.NewFileSet()
+		got, err := ctx.runString("", string(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != e {
+			t.Errorf("formatted:\n%s\ngot:\n%s\nwanted:\n%s", out, got, e)
+		}
+	}
+}
+
+func TestFormat_errors(t *testing.T) {
+	tests := []struct {
+		in, err string
+	}{
+		{"a { b: c;", "line 1: missing }"},
+		{"a { b: c; }\n}", "line 2: unexpected }"},
+		{"a { b: \"c; }", "line 1: string not terminated"},
+		{"/* a", "line 1: comment not terminated"},
+	}
+	for _, test := range tests {
+		_, err := Format([]byte(test.in))
+		if err == nil {
+			t.Errorf("%q expected error", test.in)
+			continue
+		}
+		if err.Error() != test.err {
+			t.Errorf("got: %s wanted: %s", err, test.err)
+		}
+	}
+}
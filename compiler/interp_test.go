@@ -137,3 +137,57 @@ func TestInterp_math(t *testing.T) {
 		t.Fatalf("got:\n%s\nwanted:\n%s", out, e)
 	}
 }
+
+func TestInterp_call(t *testing.T) {
+	in := `$c: #336699;
+div {
+  a: #{mix($c, #fff)};
+  b: x#{invert($c)};
+}
+.x-#{red($c)} {
+  c: d;
+}
+`
+	e := `div {
+  a: #99b3cc;
+  b: x#cc9966; }
+
+.x-51 {
+  c: d; }
+`
+	runParse(t, in, e)
+}
+
+func TestInterp_rule_name(t *testing.T) {
+	in := `$prop: color;
+$side: top;
+div {
+  #{$prop}: red;
+  margin-#{$side}: 10px;
+  border-#{$side}-width: 1px;
+}
+`
+	e := `div {
+  color: red;
+  margin-top: 10px;
+  border-top-width: 1px; }
+`
+	runParse(t, in, e)
+}
+
+func TestInterp_rule_value(t *testing.T) {
+	in := `$val: red;
+$q: "quoted";
+div {
+  a: #{$val};
+  b: #{$q};
+  c: $q;
+}
+`
+	e := `div {
+  a: red;
+  b: quoted;
+  c: "quoted"; }
+`
+	runParse(t, in, e)
+}
@@ -0,0 +1,124 @@
+package compiler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/token"
+)
+
+// Diagnostic is a problem found by Lint
+type Diagnostic struct {
+	Pos token.Position
+	Msg string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Msg)
+}
+
+// Lint parses the Sass file at path and reports variables that are
+// never referenced, mixins that are never included and properties
+// declared more than once in the same rule. No CSS is generated.
+func Lint(path string) ([]Diagnostic, error) {
+	ctx := NewContext()
+	f, err := ctx.Parse(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return lint(ctx.FileSet(), f), nil
+}
+
+// linter is the ast.Visitor checking the rules for Lint
+type linter struct {
+	fset  *token.FileSet
+	diags []Diagnostic
+}
+
+func lint(fset *token.FileSet, f *ast.File) []Diagnostic {
+	l := &linter{fset: fset}
+
+	// the parser evaluates @if, @each and friends away, so variable
+	// references are recorded while parsing
+	for _, name := range f.Unused {
+		l.report(name.Pos(), "variable %s is never used", name.Name)
+	}
+
+	var mixins []*ast.Ident
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Tok == token.MIXIN {
+			mixins = append(mixins, d.Name)
+		}
+		ast.Walk(l, decl)
+	}
+	included := make(map[string]bool)
+	for _, spec := range f.Includes {
+		included[spec.Name.Name] = true
+	}
+	for _, name := range mixins {
+		if !included[name.Name] {
+			l.report(name.Pos(), "mixin %s is never included", name.Name)
+		}
+	}
+
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		a, b := l.diags[i].Pos, l.diags[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return l.diags
+}
+
+func (l *linter) report(pos token.Pos, format string, args ...interface{}) {
+	l.diags = append(l.diags, Diagnostic{
+		Pos: l.fset.Position(pos),
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+// Visit checks the properties of each rule
+func (l *linter) Visit(node ast.Node) ast.Visitor {
+	if sel, ok := node.(*ast.SelStmt); ok {
+		l.duplicates(sel)
+	}
+	return l
+}
+
+// duplicates reports properties declared more than once directly in
+// the body of sel. Properties copied from a mixin have no position of
+// their own, they are reported at the selector.
+func (l *linter) duplicates(sel *ast.SelStmt) {
+	if sel.Body == nil {
+		return
+	}
+	props := make(map[string]bool)
+	for _, stmt := range sel.Body.List {
+		decl, ok := stmt.(*ast.DeclStmt)
+		if !ok {
+			continue
+		}
+		gen, ok := decl.Decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			rule, ok := spec.(*ast.RuleSpec)
+			if !ok {
+				continue
+			}
+			name := rule.Name.Name
+			if !props[name] {
+				props[name] = true
+				continue
+			}
+			pos := rule.Name.Pos()
+			if !pos.IsValid() {
+				pos = sel.Name.Pos()
+			}
+			l.report(pos, "property %s is declared more than once", name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package compiler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "in.scss")
+	in := `$used: 1px;
+$unused: 2px;
+$arg: 3;
+$interp: x;
+$count: 1;
+$count: $count + 1;
+@function double($n) { @return $n * 2; }
+@mixin m($p) { a: $p; }
+@mixin never() { b: c; }
+div {
+  @include m($arg);
+  w: double($used);
+  v: #{$interp}-y;
+  x: 1;
+  x: 2;
+  a: 3;
+}
+$inif: 1;
+@if $inif == 1 { p { q: r; } }
+@mixin local() { $kept: 1; $dropped: 2; s: $kept; }
+span { @include local(); $inner: 1; }
+`
+	if err := ioutil.WriteFile(path, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := Lint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := []string{
+		path + ":2:1: variable $unused is never used",
+		path + ":9:8: mixin never is never included",
+		path + ":15:3: property x is declared more than once",
+		path + ":16:3: property a is declared more than once",
+		path + ":20:28: variable $dropped is never used",
+		path + ":21:26: variable $inner is never used",
+	}
+	if len(diags) != len(e) {
+		t.Fatalf("got: %d diagnostics %v wanted: %d", len(diags), diags, len(e))
+	}
+	for i := range e {
+		if got := diags[i].String(); got != e[i] {
+			t.Errorf("got:\n%s\nwanted:\n%s", got, e[i])
+		}
+	}
+
+	if _, err := Lint(filepath.Join(dir, "missing.scss")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -119,8 +119,8 @@ div {
 }
 `
 	e := `div {
-  p01: #AbC;
-  p02: #AAbbCC;
+  p01: #abc;
+  p02: #aabbcc;
   p03: #AbChello;
   p04: #abbccd;
   p05: #aabbdd;
@@ -145,3 +145,332 @@ div {
 `
 	runParse(t, in, e)
 }
+
+func TestMath_units(t *testing.T) {
+	in := `
+div {
+  a: 10px + 5px;
+  b: 10px - 2;
+  c: 2 * 10px;
+  d: 1.5rem * 2;
+  e: (10px / 2px);
+  f: (10pt / 4);
+  g: 10deg + 5deg;
+  h: 1in + 72pt;
+}
+`
+	e := `div {
+  a: 15px;
+  b: 8px;
+  c: 20px;
+  d: 3rem;
+  e: 5;
+  f: 2.5pt;
+  g: 15deg;
+  h: 2in; }
+`
+	runParse(t, in, e)
+
+	for _, in := range []string{
+		"10px + 1em",
+		"1rem - 1px",
+		"2px * 3px",
+	} {
+		ctx := NewContext()
+		_, err := ctx.runString("", "div { a: "+in+"; }")
+		if err == nil {
+			t.Errorf("%s expected error", in)
+		}
+	}
+}
+
+func TestMath_division(t *testing.T) {
+	in := `
+$a: 10px;
+div {
+  font: 16px/1.5;
+  width: (100px / 2);
+  a: $a/2;
+  b: 1px + 10px/2;
+  c: 2 * 10px/2;
+  d: nth(1px 4px, 2)/2;
+  e: 15/3/5;
+}
+`
+	e := `div {
+  font: 16px/1.5;
+  width: 50px;
+  a: 5px;
+  b: 6px;
+  c: 10px;
+  d: 2px;
+  e: 15/3/5; }
+`
+	runParse(t, in, e)
+}
+
+func TestMath_shorthand(t *testing.T) {
+	in := `
+div {
+  grid-row: 1 / 3;
+  grid-area: 1 / 2 / 3 / 4;
+  background: url(x.png) no-repeat 0 0/cover;
+  font: bold 12px/1.4 sans-serif;
+  font: italic bold 12px/30px Georgia, serif;
+  border-radius: 10px 5% / 20px;
+}
+`
+	e := `div {
+  grid-row: 1/3;
+  grid-area: 1/2/3/4;
+  background: url(x.png) no-repeat 0 0/cover;
+  font: bold 12px/1.4 sans-serif;
+  font: italic bold 12px/30px Georgia, serif;
+  border-radius: 10px 5%/20px; }
+`
+	runParse(t, in, e)
+}
+
+func TestMath_boolean(t *testing.T) {
+	in := `$f: false;
+@mixin m($x, $y) {
+  @if $x and not $y {
+    m: yes;
+  } @else {
+    m: no;
+  }
+}
+@if $f or null {
+  a {
+    b: c;
+  }
+}
+div {
+  a: not true or true;
+  b: true and false or true;
+  c: not (1 == 2);
+  d: 1 and 2;
+  e: $f or x;
+  f: not $f;
+  @include m(true, false);
+  @include m(true, true);
+}
+`
+	e := `div {
+  a: true;
+  b: true;
+  c: true;
+  d: 2;
+  e: x;
+  f: true;
+  m: yes;
+  m: no; }
+`
+	runParse(t, in, e)
+}
+
+func TestMath_compare(t *testing.T) {
+	in := `$l: 1 2;
+$c: a, b;
+@function same($a, $b) {
+  @if $a == $b {
+    @return yes;
+  } @else {
+    @return no;
+  }
+}
+div {
+  a: 1px == 1px;
+  b: "a" == a;
+  c: (1 2) == (1 2);
+  d: (1 2) == (1 2 3);
+  e: $l != (1, 2);
+  f: $c == (a, b);
+  g: red == #ff0000;
+  h: 1 == 1px;
+  i: 1px < 2px;
+  j: 2 >= 3;
+  k: same("a", a);
+  l: 0.1 + 0.2 == 0.3;
+  m: 0.1 + 0.2 > 0.3;
+  n: 1in == 96px;
+  o: 1cm == 10mm;
+  p: 1in != 96px;
+  q: 1px == 1em;
+  r: 90deg == 0.25turn;
+}
+`
+	e := `div {
+  a: true;
+  b: true;
+  c: true;
+  d: false;
+  e: true;
+  f: true;
+  g: true;
+  h: false;
+  i: true;
+  j: false;
+  k: yes;
+  l: true;
+  m: false;
+  n: true;
+  o: true;
+  p: false;
+  q: false;
+  r: true; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	_, err := ctx.runString("", "div { a: 1px < 1em; }")
+	if err == nil {
+		t.Error("expected incompatible units error")
+	}
+}
+
+func TestMath_precision(t *testing.T) {
+	in := `$x: 1;
+div {
+  a: $x / 3;
+  b: 1.50000;
+  c: 2.0;
+  d: (2px / 3);
+  e: 1.123456789em;
+  f: 0.5 1.25000 3.0px;
+}
+`
+	e := `div {
+  a: 0.33333;
+  b: 1.5;
+  c: 2;
+  d: 0.66667px;
+  e: 1.12346em;
+  f: 0.5 1.25 3px; }
+`
+	runParse(t, in, e)
+
+	prec := 2
+	ctx, err := NewContextOptions(Options{Precision: &prec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `div {
+  a: 0.33;
+  b: 1.5;
+  c: 2;
+  d: 0.67px;
+  e: 1.12em;
+  f: 0.5 1.25 3px; }
+`
+	if out != e {
+		t.Errorf("got:\n%s\nwanted:\n%s", out, e)
+	}
+
+	if err := NewContext().SetPrecision(-1); err == nil {
+		t.Error("expected invalid precision error")
+	}
+}
+
+func TestMath_precision_compare(t *testing.T) {
+	in := `div {
+  a: 1.0001;
+  b: 1.0001 == 1;
+  c: 1.0001 > 1;
+  d: max(1.0001, 1);
+}
+`
+	e := `div {
+  a: 1.0001;
+  b: false;
+  c: true;
+  d: 1.0001; }
+`
+	runParse(t, in, e)
+
+	// numbers printed the same compare the same
+	ctx := NewContext()
+	ctx.SetPrecision(3)
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `div {
+  a: 1;
+  b: true;
+  c: false;
+  d: 1; }
+`
+	if out != e {
+		t.Errorf("got:\n%s\nwanted:\n%s", out, e)
+	}
+}
+
+func TestMath_compressed_zeros(t *testing.T) {
+	in := `$x: 1;
+div {
+  a: 0.5em;
+  b: $x - 1.5;
+  c: 0;
+  d: 10.5;
+  e: $x - 1.25px;
+  f: 0.5 0 10.05px;
+}`
+	e := `div {
+  a: 0.5em;
+  b: -0.5;
+  c: 0;
+  d: 10.5;
+  e: -0.25px;
+  f: 0.5 0 10.05px; }
+`
+	runParse(t, in, e)
+
+	ctx := NewContext()
+	ctx.SetStyle(Compressed)
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = `div{a:.5em;b:-.5;c:0;d:10.5;e:-.25px;f:.5 0 10.05px}`
+	if out != e {
+		t.Errorf("got:\n%s\nwanted:\n%s", out, e)
+	}
+}
+
+func TestMath_negative(t *testing.T) {
+	in := `$x: 5;
+$y: -5px;
+@mixin m($a) { n: -$a + 1px; }
+div {
+  a: -10px;
+  b: -$x;
+  c: 10 - 5;
+  d: 10-5;
+  e: 1px -2px;
+  f: 0 -$x;
+  g: 2 * -$x;
+  h: $y;
+  i: min(-1px, 2px);
+  j: abs(-10px);
+  @include m(2px);
+}`
+	e := `div {
+  a: -10px;
+  b: -5;
+  c: 5;
+  d: 5;
+  e: 1px -2px;
+  f: 0 -5;
+  g: -10;
+  h: -5px;
+  i: -1px;
+  j: 10px;
+  n: -1px; }
+`
+	runParse(t, in, e)
+}
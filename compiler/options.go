@@ -0,0 +1,67 @@
+package compiler
+
+import "io"
+
+// Options configures a Context created by NewContextOptions. Fields
+// left as their zero value keep the defaults of NewContext, IndentWidth
+// and Precision are pointers as 0 is a valid setting for both.
+type Options struct {
+	// Style of the CSS output, defaults to Nested
+	Style Style
+	// IndentType and IndentWidth control the indention of each level
+	// of nesting. IndentWidth defaults to 2 when nil.
+	IndentType  IndentType
+	IndentWidth *int
+	// Precision is the number of decimal places numbers are rounded
+	// to, defaults to 5 when nil
+	Precision *int
+	// Strict reports declarations that would generate invalid CSS
+	// as errors
+	Strict bool
+	// Trace prints a trace of the parsed productions
+	Trace bool
+	// RandSeed, when not 0, seeds builtins like random() making
+	// their output reproducible
+	RandSeed int64
+	// LogOutput receives @debug and @warn messages, defaults to
+	// os.Stderr
+	LogOutput io.Writer
+}
+
+// NewContextOptions returns a new context configured by opts
+func NewContextOptions(opts Options) (*Context, error) {
+	ctx := NewContext()
+	if err := ctx.SetStyle(opts.Style); err != nil {
+		return nil, err
+	}
+	if err := ctx.SetIndentType(opts.IndentType); err != nil {
+		return nil, err
+	}
+	if opts.IndentWidth != nil {
+		if err := ctx.SetIndentWidth(*opts.IndentWidth); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Precision != nil {
+		if err := ctx.SetPrecision(*opts.Precision); err != nil {
+			return nil, err
+		}
+	}
+	if err := ctx.SetStrict(opts.Strict); err != nil {
+		return nil, err
+	}
+	if err := ctx.SetTrace(opts.Trace); err != nil {
+		return nil, err
+	}
+	if opts.RandSeed != 0 {
+		if err := ctx.SetRandSeed(opts.RandSeed); err != nil {
+			return nil, err
+		}
+	}
+	if opts.LogOutput != nil {
+		if err := ctx.SetLogOutput(opts.LogOutput); err != nil {
+			return nil, err
+		}
+	}
+	return ctx, nil
+}
@@ -0,0 +1,137 @@
+package compiler
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewContextOptions(t *testing.T) {
+	in := `div {
+  p {
+    a: b; } }
+`
+	ctx, err := NewContextOptions(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ctx.runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := `div p {
+  a: b; }
+`
+	if out != e {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+
+	var log bytes.Buffer
+	ctx, err = NewContextOptions(Options{
+		IndentType: IndentTab,
+		LogOutput:  &log,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = ctx.runString("", "@debug x;\n"+in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = "div p {\n\ta: b; }\n"
+	if out != e {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+	if log.Len() == 0 {
+		t.Error("@debug was not written to LogOutput")
+	}
+
+	// 0 is a setting of its own, not the default
+	zero := 0
+	ctx, err = NewContextOptions(Options{
+		IndentWidth: &zero,
+		Precision:   &zero,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = ctx.runString("", "div { p { a: 1.6px; } }\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e = "div p {\na: 2px; }\n"
+	if out != e {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+
+	neg := -1
+	_, err = NewContextOptions(Options{Precision: &neg})
+	if err == nil {
+		t.Error("expected invalid precision error")
+	}
+
+	_, err = NewContextOptions(Options{IndentType: 5})
+	if err == nil {
+		t.Error("expected invalid indent type error")
+	}
+
+	_, err = NewContextOptions(Options{Style: 5})
+	if err == nil {
+		t.Error("expected invalid style error")
+	}
+}
+
+func TestContext_Compile(t *testing.T) {
+	ctx, err := NewContextOptions(Options{Style: Compressed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ctx.Compile([]byte(`div { color: #FFFFFF; }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := `div{color:#fff}`
+	if e != string(out) {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestContext_SetVar(t *testing.T) {
+	ctx := NewContext()
+	if err := ctx.SetVar("$brand", "#3498db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.SetVar("$gutter", "10px"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := ctx.Compile([]byte(`$brand: red !default;
+$size: 1px !default;
+$gutter: 2px;
+$font: a, b;
+$font: c, d !default;
+$stack: e, f !default;
+div { color: $brand; size: $size; margin: $gutter * 2; }
+p { font: $font; stack: $stack; }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := `div {
+  color: #3498db;
+  size: 1px;
+  margin: 4px; }
+
+p {
+  font: a, b;
+  stack: e, f; }
+`
+	if e != string(out) {
+		t.Errorf("got:\n%s\nwanted:\n%s", out, e)
+	}
+
+	if err := ctx.SetVar("brand", "red"); err == nil {
+		t.Error("expected invalid name error")
+	}
+	if err := ctx.SetVar("$brand", "red; }"); err == nil {
+		t.Error("expected invalid value error")
+	}
+}
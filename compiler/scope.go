@@ -5,10 +5,17 @@ import "github.com/wellington/sass/ast"
 // stores types and values with scoping. To remove a scope
 // use CloseScope(), to open a new Scope use OpenScope().
 type Scope interface {
-	// OpenScope() Typ
-	// CloseScope() Typ
-	// Lookup(string) interface{}
-	// Insert(string, interface{})
+	// Lookup finds the value of a variable in this scope or
+	// any of its outer scopes, nil is returned if not found
+	Lookup(string) interface{}
+	// Set assigns a variable following Sass rules. A variable
+	// found in an enclosing block is modified, otherwise a local
+	// variable is created shadowing any global of the same name.
+	Set(string, interface{})
+	// SetLocal declares a variable in this scope
+	SetLocal(string, interface{})
+	// SetGlobal assigns a variable in the outermost scope ie. !global
+	SetGlobal(string, interface{})
 	// Number of Rules in this scope
 	RuleAdd(*ast.RuleSpec)
 	RuleLen() int
@@ -23,9 +30,9 @@ var (
 
 type emptyTyp struct{}
 
-// func (*emptyTyp) Lookup(name string) interface{} {
-// 	return nil
-// }
+func (*emptyTyp) Lookup(name string) interface{} {
+	return nil
+}
 
 func (*emptyTyp) RegisterMixin(_ string, _ int, _ *MixFn) {}
 
@@ -33,7 +40,11 @@ func (*emptyTyp) Mixin(_ string, _ int) (*MixFn, error) {
 	return nil, ErrMixinNotFound
 }
 
-// func (*emptyTyp) Insert(name string, _ interface{}) {}
+func (*emptyTyp) Set(name string, _ interface{}) {}
+
+func (*emptyTyp) SetLocal(name string, _ interface{}) {}
+
+func (*emptyTyp) SetGlobal(name string, _ interface{}) {}
 
 func (*emptyTyp) RuleLen() int { return 0 }
 
@@ -45,6 +56,41 @@ type valueScope struct {
 	m     map[string]interface{}
 }
 
+func (t *valueScope) Lookup(name string) interface{} {
+	if v, ok := t.m[name]; ok {
+		return v
+	}
+	return t.Scope.Lookup(name)
+}
+
+func (t *valueScope) Set(name string, v interface{}) {
+	for s := t; !s.global(); s = s.Scope.(*valueScope) {
+		if _, ok := s.m[name]; ok {
+			s.m[name] = v
+			return
+		}
+	}
+	t.m[name] = v
+}
+
+func (t *valueScope) SetLocal(name string, v interface{}) {
+	t.m[name] = v
+}
+
+func (t *valueScope) SetGlobal(name string, v interface{}) {
+	s := t
+	for !s.global() {
+		s = s.Scope.(*valueScope)
+	}
+	s.m[name] = v
+}
+
+// global reports whether t is the outermost scope
+func (t *valueScope) global() bool {
+	_, ok := t.Scope.(*valueScope)
+	return !ok
+}
+
 func (t *valueScope) RuleAdd(rule *ast.RuleSpec) {
 	t.rules = append(t.rules, rule)
 }
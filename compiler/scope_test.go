@@ -0,0 +1,132 @@
+package compiler
+
+import "testing"
+
+func TestScope_lookup(t *testing.T) {
+	s := NewScope(empty)
+	s.SetLocal("$x", "1")
+	s = NewScope(s)
+	if v := s.Lookup("$x"); v != "1" {
+		t.Errorf("got: %v wanted: 1", v)
+	}
+	s.SetLocal("$x", "2")
+	if v := s.Lookup("$x"); v != "2" {
+		t.Errorf("got: %v wanted: 2", v)
+	}
+	s = CloseScope(s)
+	if v := s.Lookup("$x"); v != "1" {
+		t.Errorf("got: %v wanted: 1", v)
+	}
+	if v := s.Lookup("$y"); v != nil {
+		t.Errorf("got: %v wanted: nil", v)
+	}
+}
+
+func TestScope_set(t *testing.T) {
+	global := NewScope(empty)
+	global.Set("$x", "global")
+
+	// a block shadows globals
+	block := NewScope(global)
+	block.Set("$x", "block")
+	if v := block.Lookup("$x"); v != "block" {
+		t.Errorf("got: %v wanted: block", v)
+	}
+	if v := global.Lookup("$x"); v != "global" {
+		t.Errorf("got: %v wanted: global", v)
+	}
+
+	// nested blocks modify the variable of an enclosing block
+	inner := NewScope(block)
+	inner.Set("$x", "inner")
+	if v := block.Lookup("$x"); v != "inner" {
+		t.Errorf("got: %v wanted: inner", v)
+	}
+
+	// SetLocal always shadows
+	inner.SetLocal("$x", "local")
+	if v := block.Lookup("$x"); v != "inner" {
+		t.Errorf("got: %v wanted: inner", v)
+	}
+
+	// SetGlobal reaches the outermost scope
+	inner.SetGlobal("$x", "changed")
+	if v := CloseScope(CloseScope(inner)).Lookup("$x"); v != "changed" {
+		t.Errorf("got: %v wanted: changed", v)
+	}
+	if v := inner.Lookup("$x"); v != "local" {
+		t.Errorf("got: %v wanted: local", v)
+	}
+}
+
+func TestScope_shadow(t *testing.T) {
+	in := `$x: 1;
+div {
+  $x: 2;
+  a: $x;
+  p {
+    b: $x;
+  }
+}
+e {
+  c: $x;
+}`
+	e := `div {
+  a: 2; }
+  div p {
+    b: 2; }
+
+e {
+  c: 1; }
+`
+	runParse(t, in, e)
+}
+
+func TestScope_set_compile(t *testing.T) {
+	// nested blocks modify the variable of an enclosing block
+	in := `a {
+  $x: 2;
+  c {
+    $x: 3;
+  }
+  e: $x;
+}`
+	e := `a {
+  e: 3; }
+`
+	runParse(t, in, e)
+
+	// a block shadows globals
+	in = `$x: 1;
+a {
+  $x: 2;
+  b: $x;
+}
+c {
+  d: $x;
+}`
+	e = `a {
+  b: 2; }
+
+c {
+  d: 1; }
+`
+	runParse(t, in, e)
+
+	// !global modifies the global
+	in = `$x: 1;
+a {
+  $x: 2 !global;
+  b: $x;
+}
+c {
+  d: $x;
+}`
+	e = `a {
+  b: 2; }
+
+c {
+  d: 2; }
+`
+	runParse(t, in, e)
+}
@@ -0,0 +1,57 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/ast/unit"
+	"github.com/wellington/sass/calc"
+	"github.com/wellington/sass/token"
+)
+
+// validUnits reports multiplication of two numbers with units
+// ie. 2px * 2px, CSS has no representation for px*px. Operands are
+// checked by the value they resolve to ie. $a * $a
+func (ctx *Context) validUnits(values []ast.Expr) error {
+	var err error
+	for _, val := range values {
+		ast.Inspect(val, func(n ast.Node) bool {
+			bin, ok := n.(*ast.BinaryExpr)
+			if !ok || err != nil {
+				return err == nil
+			}
+			if bin.Op != token.MUL {
+				return true
+			}
+			x, xerr := calc.Resolve(bin.X, true, ctx.precision)
+			y, yerr := calc.Resolve(bin.Y, true, ctx.precision)
+			if xerr != nil || yerr != nil || !hasUnit(x) || !hasUnit(y) {
+				return true
+			}
+			err = ctx.errorf(bin.OpPos, "%s %s %s isn't a valid CSS value",
+				x.Value, bin.Op, y.Value)
+			return false
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validValue reports declarations that resolved to an empty value
+func (ctx *Context) validValue(spec *ast.RuleSpec, value string) error {
+	if len(strings.TrimSpace(value)) == 0 {
+		return ctx.errorf(spec.Pos(), "property %q has no value", spec.Name)
+	}
+	return nil
+}
+
+// hasUnit reports whether lit is a number with a unit attached
+func hasUnit(lit *ast.BasicLit) bool {
+	if lit.Kind == token.UPCT {
+		return true
+	}
+	num, err := unit.NewNum(lit)
+	return err == nil && num.Unit != unit.NOUNIT
+}
@@ -0,0 +1,84 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/wellington/sass/token"
+)
+
+func runStrict(t *testing.T, in string) (string, error) {
+	ctx := NewContext()
+	ctx.SetStrict(true)
+	ctx.fset = token.NewFileSet()
+	return ctx.runString("", in)
+}
+
+func TestStrict_units(t *testing.T) {
+	in := `div {
+  width: 2px * 2px;
+}`
+	_, err := runStrict(t, in)
+	if err == nil {
+		t.Fatal("expected error for px*px")
+	}
+	e := "2:14: 2px * 2px isn't a valid CSS value"
+	if err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+}
+
+func TestStrict_valid(t *testing.T) {
+	in := `div {
+  width: 2px * 2;
+  height: 4px + 2px;
+}`
+	out, err := runStrict(t, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := `div {
+  width: 4px;
+  height: 6px; }
+`
+	if e != out {
+		t.Errorf("got:\n%q\nwanted:\n%q", out, e)
+	}
+}
+
+func TestStrict_units_resolved(t *testing.T) {
+	in := `$a: 2px;
+div {
+  width: $a * $a;
+}`
+	_, err := runStrict(t, in)
+	if err == nil {
+		t.Fatal("expected error for $a * $a")
+	}
+	e := "3:13: 2px * 2px isn't a valid CSS value"
+	if err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+}
+
+func TestStrict_no_value(t *testing.T) {
+	in := `div {
+  width: ;
+}`
+	_, err := runStrict(t, in)
+	if err == nil {
+		t.Fatal("expected error for missing value")
+	}
+	e := `2:3: property "width" has no value`
+	if err.Error() != e {
+		t.Errorf("got: %s wanted: %s", err, e)
+	}
+
+	// without strict the declaration is omitted
+	out, err := NewContext().runString("", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("got: %q wanted no output", out)
+	}
+}
@@ -12,3 +12,40 @@ div {
   x: 1 2 3; }
 `)
 }
+
+func TestType_trailing_comma(t *testing.T) {
+	runParse(t, `
+$x: (1, 2, 3,);
+$m: (a: 1, b: 2,);
+div {
+  a: $x;
+  b: nth((1, 2, 3,), 3);
+  c: length($x);
+  d: list-separator((a,));
+  e: map-get($m, b);
+}`,
+		`div {
+  a: 1, 2, 3;
+  b: 3;
+  c: 3;
+  d: comma;
+  e: 2; }
+`)
+}
+
+func TestType_empty_list(t *testing.T) {
+	runParse(t, `
+$x: ();
+div {
+  a: length(());
+  b: length($x);
+  c: inspect($x);
+  d: map-has-key($x, a);
+}`,
+		`div {
+  a: 0;
+  b: 0;
+  c: ();
+  d: false; }
+`)
+}
@@ -9,12 +9,16 @@ import (
 	"github.com/wellington/sass/ast"
 	"github.com/wellington/sass/builtin"
 	"github.com/wellington/sass/calc"
+	"github.com/wellington/sass/strops"
 	"github.com/wellington/sass/token"
 
 	// Include defined builtins
 	_ "github.com/wellington/sass/builtin/colors"
 	_ "github.com/wellington/sass/builtin/introspect"
 	_ "github.com/wellington/sass/builtin/list"
+	_ "github.com/wellington/sass/builtin/maps"
+	_ "github.com/wellington/sass/builtin/numbers"
+	_ "github.com/wellington/sass/builtin/selectors"
 	_ "github.com/wellington/sass/builtin/strops"
 	_ "github.com/wellington/sass/builtin/url"
 )
@@ -26,6 +30,7 @@ type call struct {
 	params []*ast.KeyValueExpr
 	ch     builtin.CallFunc
 	handle builtin.CallHandle
+	env    builtin.EnvFunc
 }
 
 func (c *call) Pos(key *ast.Ident) int {
@@ -42,6 +47,16 @@ func (c *call) Pos(key *ast.Ident) int {
 	return -1
 }
 
+// variadic reports whether the last parameter accepts any number
+// of arguments ie. $args...
+func (c *call) variadic() bool {
+	if len(c.params) == 0 {
+		return false
+	}
+	ident, ok := c.params[len(c.params)-1].Key.(*ast.Ident)
+	return ok && strings.HasSuffix(ident.Name, "...")
+}
+
 type desc struct {
 	err error
 	c   call
@@ -86,9 +101,21 @@ var builtins = make(map[string]call)
 
 func init() {
 	builtin.BindRegister(register)
+	builtin.BindRegisterEnv(registerEnv)
 }
 
 func register(s string, ch builtin.CallFunc, h builtin.CallHandle) {
+	registerCall(s, call{
+		ch:     ch,
+		handle: h,
+	})
+}
+
+func registerEnv(s string, ch builtin.EnvFunc) {
+	registerCall(s, call{env: ch})
+}
+
+func registerCall(s string, c call) {
 	fset := token.NewFileSet()
 	pf, err := ParseFile(fset, "", s, FuncOnly)
 	if err != nil {
@@ -96,10 +123,7 @@ func register(s string, ch builtin.CallFunc, h builtin.CallHandle) {
 			log.Fatal(err)
 		}
 	}
-	d := &desc{c: call{
-		ch:     ch,
-		handle: h,
-	}}
+	d := &desc{c: c}
 	ast.Walk(d, pf.Decls[0])
 	if d.err != nil {
 		log.Fatal("failed to parse func description", d.err)
@@ -117,18 +141,112 @@ func evaluateCall(p *parser, scope *ast.Scope, expr *ast.CallExpr) (ast.Expr, er
 
 	// First check builtins
 	if fn, ok := builtins[name]; ok {
-		return callBuiltin(name, fn, expr)
+		return callBuiltin(p.env, name, fn, expr)
+	}
+	if ident.Obj == nil {
+		p.tryResolve(ident, false)
+	}
+	if ident.Obj == nil {
+		return p.callCSS(expr)
 	}
 	return p.callInline(scope, expr)
 }
 
+// callCSS handles functions unknown to Sass ie. counter(item). These
+// are plain CSS and are printed as written with their arguments resolved.
+// Math inside calc() is left for the browser.
+func (p *parser) callCSS(call *ast.CallExpr) (ast.Expr, error) {
+	name := call.Fun.(*ast.Ident).Name
+	args := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		x, err := p.resolveCall(arg)
+		if err != nil {
+			return nil, err
+		}
+		if name == "calc" {
+			args[i], err = literalExpr(x, p.env.Precision)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		lit, err := calc.Resolve(x, true, p.env.Precision)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = cssValue(lit)
+	}
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		ValuePos: call.Pos(),
+		Value:    name + "(" + strings.Join(args, ", ") + ")",
+	}, nil
+}
+
+// literalExpr prints x without performing any operations. Only
+// interpolation is resolved, calc(100% - $x) is left as is.
+func literalExpr(x ast.Expr, prec int) (string, error) {
+	switch v := x.(type) {
+	case *ast.Ident:
+		return v.Name, nil
+	case *ast.BinaryExpr:
+		l, err := literalExpr(v.X, prec)
+		if err != nil {
+			return "", err
+		}
+		r, err := literalExpr(v.Y, prec)
+		if err != nil {
+			return "", err
+		}
+		return l + " " + v.Op.String() + " " + r, nil
+	case *ast.UnaryExpr:
+		s, err := literalExpr(v.X, prec)
+		if err != nil {
+			return "", err
+		}
+		return v.Op.String() + s, nil
+	case *ast.ListLit:
+		delim := " "
+		if v.Comma {
+			delim = ", "
+		}
+		ss := make([]string, len(v.Value))
+		for i := range v.Value {
+			s, err := literalExpr(v.Value[i], prec)
+			if err != nil {
+				return "", err
+			}
+			ss[i] = s
+		}
+		s := strings.Join(ss, delim)
+		if v.Paren {
+			s = "(" + s + ")"
+		}
+		return s, nil
+	}
+	lit, err := calc.Resolve(x, false, prec)
+	if err != nil {
+		return "", err
+	}
+	return cssValue(lit), nil
+}
+
+// cssValue returns the value of lit as written in CSS, quoted strings
+// keep their quotes.
+func cssValue(lit *ast.BasicLit) string {
+	if lit.Kind == token.QSTRING {
+		return strops.Wrap(lit.Value)
+	}
+	return lit.Value
+}
+
 // callInline looks for the function within Sass itself
 func (p *parser) callInline(scope *ast.Scope, call *ast.CallExpr) (ast.Expr, error) {
 
 	return p.resolveFuncDecl(scope, call)
 }
 
-func callBuiltin(name string, fn call, expr *ast.CallExpr) (ast.Expr, error) {
+func callBuiltin(env *builtin.Env, name string, fn call, expr *ast.CallExpr) (ast.Expr, error) {
 
 	// Walk through the function
 	// These should be processed at registration time
@@ -143,6 +261,12 @@ func callBuiltin(name string, fn call, expr *ast.CallExpr) (ast.Expr, error) {
 	var argpos int
 	incoming := expr.Args
 
+	// Variadic arguments are passed as additional args
+	if fn.variadic() && len(callargs) < len(incoming) {
+		callargs = append(callargs,
+			make([]ast.Expr, len(incoming)-len(callargs))...)
+	}
+
 	// Verify args and convert to BasicLit before passing along
 	if len(callargs) < len(incoming) {
 		for i, p := range incoming {
@@ -166,6 +290,20 @@ func callBuiltin(name string, fn call, expr *ast.CallExpr) (ast.Expr, error) {
 			callargs[pos] = v.Value.(*ast.BasicLit)
 		case *ast.ListLit:
 			callargs[argpos] = v
+		case *ast.MapLit:
+			callargs[argpos] = v
+		case *ast.CallExpr:
+			// calls resolving to lists and maps pass them along
+			switch r := v.Resolved.(type) {
+			case *ast.ListLit, *ast.MapLit:
+				callargs[argpos] = r
+				continue
+			}
+			lit, err := calc.Resolve(v, true, env.Precision)
+			if err != nil {
+				return nil, err
+			}
+			callargs[argpos] = lit
 		case *ast.Ident:
 			if v.Obj != nil {
 				ass := v.Obj.Decl.(*ast.AssignStmt)
@@ -175,7 +313,7 @@ func callBuiltin(name string, fn call, expr *ast.CallExpr) (ast.Expr, error) {
 			}
 
 		default:
-			lit, err := calc.Resolve(v, true)
+			lit, err := calc.Resolve(v, true, env.Precision)
 			if err == nil {
 				callargs[argpos] = lit
 			} else {
@@ -183,16 +321,19 @@ func callBuiltin(name string, fn call, expr *ast.CallExpr) (ast.Expr, error) {
 			}
 		}
 	}
-	if fn.ch != nil {
+	if fn.ch != nil || fn.env != nil {
 		lits := make([]*ast.BasicLit, len(callargs))
 		var err error
 		for i, x := range callargs {
-			lits[i], err = calc.Resolve(x, true)
+			lits[i], err = calc.Resolve(x, true, env.Precision)
 			// lits[i], ok = exprToLit(x)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse arg(%d) in %s: %s", i, fn.name, err)
 			}
 		}
+		if fn.env != nil {
+			return fn.env(env, expr, lits...)
+		}
 		return fn.ch(expr, lits...)
 	}
 	return fn.handle(expr, callargs...)
@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/token"
+)
+
+func (p *parser) parseExtendStmt() *ast.ExtendStmt {
+	if p.trace {
+		defer un(trace(p, "ExtendStmt"))
+	}
+
+	pos := p.expect(token.EXTEND)
+	stmt := &ast.ExtendStmt{
+		Extend: pos,
+		Sel: &ast.BasicLit{
+			ValuePos: p.pos,
+			Kind:     token.STRING,
+			Value:    p.lit,
+		},
+	}
+	p.expect(token.STRING)
+	if p.tok == token.STRING && p.lit == "!optional" {
+		stmt.Optional = true
+		p.next()
+	}
+	p.expectSemi()
+
+	if len(p.sels) == 0 {
+		p.error(pos, "Extend directives may only be used within rules.")
+		return stmt
+	}
+	stmt.Parent = p.sels[len(p.sels)-1]
+	p.extends = append(p.extends, stmt)
+	return stmt
+}
+
+// resolveExtends merges the selectors containing @extend into the
+// selectors they target. Placeholder selectors are removed once
+// all extends have been applied.
+func (p *parser) resolveExtends(decls []ast.Decl) {
+	if len(p.extends) == 0 && !p.placeholders {
+		return
+	}
+
+	var sels []*ast.SelStmt
+	for _, decl := range decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch v := n.(type) {
+			case *ast.FuncDecl:
+				// Mixins are extended where they are included
+				return false
+			case *ast.SelStmt:
+				sels = append(sels, v)
+			}
+			return true
+		})
+	}
+
+	found := make(map[*ast.ExtendStmt]bool)
+	for _, sel := range sels {
+		if sel.Resolved == nil {
+			continue
+		}
+		// selectors nested in an extended parent ie. %btn { &.active {} }
+		// keep the parent they were resolved against, the extend
+		// applies to them directly
+		groups := ast.SplitGroups(sel.Resolved.Value)
+		var exts []ast.Extension
+		// Extended selectors may themselves be extended
+		for i := 0; i < len(groups); i++ {
+			for _, ext := range p.extends {
+				ns, ok := ast.ExtendGroup(groups[i],
+					ext.Sel.Value, ext.Parent.Resolved.Value)
+				if !ok {
+					continue
+				}
+				found[ext] = true
+				for _, n := range ns {
+					if contains(groups, n) {
+						continue
+					}
+					groups = append(groups, n)
+					exts = append(exts, ast.Extension{
+						Pos: ext.Pos(),
+						Sel: n,
+					})
+				}
+			}
+		}
+		if len(exts) > 0 {
+			sel.Resolved.Value = ast.ExtendOrder(sel.Resolved.Value, exts)
+		}
+	}
+
+	for _, ext := range p.extends {
+		if found[ext] || ext.Optional {
+			continue
+		}
+		p.error(ext.Pos(), fmt.Sprintf(
+			"%q failed to @extend %q. The selector %q was not found. "+
+				`Use "@extend %s !optional" if the extend should be able to fail.`,
+			ext.Parent.Resolved.Value, ext.Sel.Value,
+			ext.Sel.Value, ext.Sel.Value))
+	}
+
+	// Placeholders are never output
+	for _, sel := range sels {
+		if sel.Resolved == nil {
+			continue
+		}
+		var keep []string
+		for _, group := range ast.SplitGroups(sel.Resolved.Value) {
+			if !hasPlaceholder(group) {
+				keep = append(keep, group)
+			}
+		}
+		sel.Resolved.Value = strings.Join(keep, ", ")
+	}
+}
+
+// hasPlaceholder reports whether sel contains a placeholder selector
+// ie. %name. Percentages ie. 50% in @keyframes or attribute values
+// ie. [width="50%"] are not placeholders.
+func hasPlaceholder(sel string) bool {
+	var quote byte
+	depth := 0
+	for i := 0; i < len(sel); i++ {
+		ch := sel[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[' || ch == '(':
+			depth++
+		case ch == ']' || ch == ')':
+			depth--
+		case ch == '%' && depth == 0 && i+1 < len(sel):
+			next := sel[i+1]
+			if next == '-' || next == '_' || unicode.IsLetter(rune(next)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
+	"github.com/wellington/sass/scanner"
+	"github.com/wellington/sass/token"
+)
+
+// writeFiles writes files to a new temporary directory
+func writeFiles(tb testing.TB, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "import")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			os.RemoveAll(dir)
+			tb.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// selPositions returns the resolved selectors of f and where they
+// were found
+func selPositions(fset *token.FileSet, f *ast.File) []string {
+	var sels []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelStmt); ok {
+			sels = append(sels, fmt.Sprintf("%s %s",
+				fset.Position(sel.Name.Pos()), sel.Resolved.Value))
+		}
+		return true
+	})
+	return sels
+}
+
+func TestImport_cache(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"_part.scss": "\n.part { w: $x; }\n",
+		"main.scss": `$x: 1px;
+@import "part";
+$x: 2px;
+@import "part";
+`,
+	})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "main.scss")
+
+	fset := token.NewFileSet()
+	e, err := ParseFile(fset, path, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := selPositions(fset, e)
+
+	env := builtin.NewEnv()
+	env.Imports = scanner.NewCache()
+	fset = token.NewFileSet()
+	f, err := ParseFileEnv(fset, path, nil, 0, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := selPositions(fset, f)
+
+	if env.Imports.Len() != 1 {
+		t.Errorf("got: %d cached files wanted: 1", env.Imports.Len())
+	}
+	if len(got) != 2 || len(got) != len(want) {
+		t.Fatalf("got: %v wanted: %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got: %s wanted: %s", got[i], want[i])
+		}
+	}
+
+	// each import is evaluated where it is imported
+	var vals []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		rule, ok := n.(*ast.RuleSpec)
+		if !ok {
+			return true
+		}
+		ident := rule.Values[0].(*ast.Ident)
+		assign := ident.Obj.Decl.(*ast.AssignStmt)
+		vals = append(vals, assign.Rhs[0].(*ast.BasicLit).Value)
+		return true
+	})
+	if len(vals) != 2 || vals[0] != "1px" || vals[1] != "2px" {
+		t.Errorf("got: %v wanted: [1px 2px]", vals)
+	}
+}
+
+// importGraph writes a chain of depth files each importing the next
+// and a shared partial
+func importGraph(b *testing.B, depth int) (string, string) {
+	files := map[string]string{
+		"_base.scss": `$a: 1px !default;
+@mixin m($x) { margin: $x; padding: $x 0; }
+.base { color: red; width: $a + 1px; }
+.base-nested { .inner { height: 10px; } }
+`,
+	}
+	for i := 0; i < depth; i++ {
+		src := fmt.Sprintf("@import \"base\";\n.level-%d { @include m(%dpx); }\n", i, i)
+		if i+1 < depth {
+			src += fmt.Sprintf("@import \"level%d\";\n", i+1)
+		}
+		files[fmt.Sprintf("_level%d.scss", i)] = src
+	}
+	files["main.scss"] = "@import \"level0\";\n"
+	dir := writeFiles(b, files)
+	return dir, filepath.Join(dir, "main.scss")
+}
+
+func benchmarkImport(b *testing.B, cached bool) {
+	dir, path := importGraph(b, 20)
+	defer os.RemoveAll(dir)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		env := builtin.NewEnv()
+		if cached {
+			env.Imports = scanner.NewCache()
+		}
+		if _, err := ParseFileEnv(token.NewFileSet(), path, nil, 0, env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkImport_cached(b *testing.B) {
+	benchmarkImport(b, true)
+}
+
+func BenchmarkImport_uncached(b *testing.B) {
+	benchmarkImport(b, false)
+}
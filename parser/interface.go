@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
 	"github.com/wellington/sass/token"
 )
 
@@ -83,6 +84,14 @@ const (
 // are returned via a scanner.ErrorList which is sorted by file position.
 //
 func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode) (f *ast.File, err error) {
+	return ParseFileEnv(fset, filename, src, mode, builtin.NewEnv())
+}
+
+// ParseFileEnv is ParseFile with builtins sharing the provided env. Pass
+// an Env created by builtin.NewEnvSeed to make builtins like
+// unique-id() return the same results on every parse. A nil env is
+// replaced by builtin.NewEnv().
+func ParseFileEnv(fset *token.FileSet, filename string, src interface{}, mode Mode, env *builtin.Env) (f *ast.File, err error) {
 	// get source
 	text, err := readSource(filename, src)
 	if err != nil {
@@ -115,6 +124,10 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 
 	// parse source
 	p.init(fset, filename, text, mode)
+	if env == nil {
+		env = builtin.NewEnv()
+	}
+	p.env = env
 	p.next()
 	f = p.parseFile()
 
@@ -194,6 +207,7 @@ func ParseExprFrom(fset *token.FileSet, filename string, src interface{}, mode M
 
 	// parse expr
 	p.init(fset, filename, text, mode)
+	p.env = builtin.NewEnv()
 	p.next()
 	// Set up pkg-level scopes to avoid nil-pointer errors.
 	// This is not needed for a correct expression x as the
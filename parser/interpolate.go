@@ -1,6 +1,9 @@
 package parser
 
-import "github.com/wellington/sass/ast"
+import (
+	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/token"
+)
 
 // mergeExprs looks for interpolation and performs literal merges
 // The return is just a string, so YMMV
@@ -27,6 +30,19 @@ func itpExpand(left, right ast.Expr) string {
 		s += v.Obj.Decl.(*ast.BasicLit).Value
 	case *ast.BasicLit:
 		s += v.Value
+	case *ast.BinaryExpr:
+		// selectors following interpolation ie. #{$a}-b, c
+		x, y := itpExpand(v.X, nil), itpExpand(v.Y, nil)
+		if v.Op == token.COMMA {
+			s += x + ", " + y
+		} else {
+			s += x + " " + v.Op.String() + " " + y
+		}
+	case *ast.UnaryExpr:
+		if v.Op != token.NEST {
+			s += v.Op.String() + " "
+		}
+		s += itpExpand(v.X, nil)
 	}
 	if right != nil {
 		if left.End() < right.Pos() {
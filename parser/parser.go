@@ -11,6 +11,7 @@ import (
 	"unicode"
 
 	"github.com/wellington/sass/ast"
+	"github.com/wellington/sass/builtin"
 	"github.com/wellington/sass/calc"
 	"github.com/wellington/sass/scanner"
 	"github.com/wellington/sass/strops"
@@ -24,11 +25,22 @@ func init() {
 type stack struct {
 	file    *token.File
 	scanner scanner.Scanner
+	replay  []scanner.Item
+	record  *record
 	pos     token.Pos
 	tok     token.Token
 	lit     string
 	syncPos token.Pos
 	syncCnt int
+	// imports of the same list waiting to be read
+	queue []queue
+}
+
+// record collects the tokens of an import for the import cache
+type record struct {
+	file  *scanner.CachedFile
+	items []scanner.Item
+	errs  int // errors found before the import
 }
 
 type triplet struct {
@@ -51,11 +63,16 @@ type parser struct {
 	// Parser state is pushed onto importStack while imports
 	// are being scanned and parsed.
 	imps      []stack
-	queue     *queue // queued file for import, starts a new scanner
+	queue     []queue // queued files for import, each starts a new scanner
 	lookahead triplet
 	inSel     bool // controler selector logic
 	prescan   bool // control interpolation joining
 
+	// Imports found in the import cache are replayed instead of
+	// scanned, others are recorded while scanned
+	replay []scanner.Item
+	record *record
+
 	// Tracing/debugging
 	mode   Mode // parsing mode
 	trace  bool // == (mode & Trace != 0)
@@ -80,16 +97,30 @@ type parser struct {
 	syncCnt int       // number of calls to syncXXX without progress
 
 	// Non-syntactic parser control
-	exprLev int            // < 0: in control clause, >= 0: in expression
-	inRhs   bool           // if set, the parser is parsing a rhs expression
-	inMixin bool           // special rules for mixins
-	sels    []*ast.SelStmt // current list of nested selectors
+	exprLev  int            // < 0: in control clause, >= 0: in expression
+	inRhs    bool           // if set, the parser is parsing a rhs expression
+	inMixin  bool           // special rules for mixins
+	inEach   int            // depth of @each bodies, evaluated when resolved
+	inString int            // depth of quoted strings, words are never colors
+	sels     []*ast.SelStmt // current list of nested selectors
+	rootSel  *ast.SelStmt   // parent left by @at-root, the target of &
+
+	extends      []*ast.ExtendStmt // @extend found while parsing
+	placeholders bool              // a placeholder selector was found
+
+	env *builtin.Env // state shared by builtins ie. unique-id()
 
 	// Ordinary identifier scopes
-	pkgScope   *ast.Scope        // pkgScope.Outer == nil
-	topScope   *ast.Scope        // top-most scope; may be pkgScope
-	unresolved []*ast.Ident      // unresolved identifiers
-	imports    []*ast.ImportSpec // list of imports
+	pkgScope   *ast.Scope         // pkgScope.Outer == nil
+	topScope   *ast.Scope         // top-most scope; may be pkgScope
+	unresolved []*ast.Ident       // unresolved identifiers
+	imports    []*ast.ImportSpec  // list of imports
+	includes   []*ast.IncludeSpec // list of @include
+
+	// Variable usage, reported by ast.File.Unused
+	vars    []*ast.Ident              // variables in the order they are declared
+	varPos  map[*ast.Object]token.Pos // declaration of each variable object
+	varUsed map[token.Pos]bool        // declarations referenced while parsing
 
 	// Label scopes
 	// (maintained by open/close LabelScope)
@@ -151,28 +182,90 @@ func (p *parser) add(filename string, src interface{}) error {
 	if err != nil {
 		return err
 	}
-	p.queue = &queue{filename: abs, src: src}
+	p.queue = append(p.queue, queue{filename: abs, src: src})
+	return nil
+}
+
+// readImport starts scanning an imported file. When the Env has an
+// import cache, files scanned before are replayed from the cache and
+// the tokens of new files are recorded.
+func (p *parser) readImport(filename string, src interface{}) error {
+	var cache *scanner.Cache
+	if src == nil && p.env != nil {
+		cache = p.env.Imports
+	}
+	if cache != nil {
+		if cached, ok := cache.Get(filename); ok && cached.Items != nil {
+			p.file = Globalfset.AddFile(filename, -1, len(cached.Src))
+			p.file.SetLinesForContent(cached.Src)
+			p.replay, p.record = cached.Items, nil
+			return nil
+		}
+	}
+	text, err := readSource(filename, src)
+	if err != nil {
+		return err
+	}
+	p.init(Globalfset, filename, text, p.mode)
+	p.replay, p.record = nil, nil
+	if cache != nil {
+		p.record = &record{
+			file: cache.Put(filename, text),
+			errs: p.errors.Len(),
+		}
+	}
 	return nil
 }
 
+// scan returns the next token of the current file
+func (p *parser) scan() (token.Pos, token.Token, string) {
+	if p.replay != nil {
+		item := p.replay[0]
+		// EOF is returned until the import is popped
+		if len(p.replay) > 1 {
+			p.replay = p.replay[1:]
+		}
+		return p.file.Pos(item.Pos), item.Type, item.Value
+	}
+	pos, tok, lit := p.scanner.Scan()
+	if rec := p.record; rec != nil {
+		rec.items = append(rec.items, scanner.Item{
+			Type:  tok,
+			Pos:   p.file.Offset(pos),
+			Value: lit,
+		})
+		if tok == token.EOF {
+			// imports with errors are scanned again
+			if p.errors.Len() == rec.errs {
+				rec.file.Items = rec.items
+			}
+			p.record = nil
+		}
+	}
+	return pos, tok, lit
+}
+
 func (p *parser) pop() error {
-	if p.queue == nil {
-		return fmt.Errorf("pop() called with nil queue")
+	if len(p.queue) == 0 {
+		return fmt.Errorf("pop() called with empty queue")
 	}
 	stk := stack{
 		file:    p.file,
 		scanner: p.scanner,
+		replay:  p.replay,
+		record:  p.record,
 		pos:     p.pos,
 		tok:     p.tok,
 		lit:     p.lit,
 		syncPos: p.syncPos,
 		syncCnt: p.syncCnt,
+		queue:   p.queue[1:],
 	}
 	p.imps = append(p.imps, stk)
 
-	filename, src := p.queue.filename, p.queue.src
+	filename, src := p.queue[0].filename, p.queue[0].src
 	p.queue = nil
-	text, err := readSource(filename, src)
+	err := p.readImport(filename, src)
 	if err != nil {
 		abs, ferr := filepath.Abs(filename)
 		if ferr != nil {
@@ -181,11 +274,6 @@ func (p *parser) pop() error {
 		err = fmt.Errorf("failed to read: %s", err, abs)
 		return err
 	}
-	if p.queue != nil {
-		panic("queue hasn't been flushed")
-	}
-	p.init(Globalfset, filename, text, p.mode)
-
 	return nil
 }
 
@@ -301,15 +389,18 @@ func (p *parser) shortVarDecl(decl *ast.AssignStmt, list []ast.Expr) {
 				if ident.Global {
 					fmt.Println("Storing Global...", obj.Name)
 				}
-				if alt := p.topScope.Insert(obj, ident.Global); alt != nil {
+				if alt := p.topScope.Set(obj, ident.Global); alt != nil {
 					if p.trace {
 						fmt.Printf("forcefully updated %s (%p): % #v\n", ident,
 
 							ident, decl)
 					}
 					ident.Obj = alt // redeclaration
+					p.declareVar(obj, p.varPos[alt])
 				} else {
 					n++ // new declaration
+					p.declareVar(obj, ident.Pos())
+					p.vars = append(p.vars, ident)
 				}
 			}
 		} else {
@@ -321,6 +412,32 @@ func (p *parser) shortVarDecl(decl *ast.AssignStmt, list []ast.Expr) {
 	}
 }
 
+// declareVar remembers that obj was declared at pos. Reassignments
+// keep the position of the original declaration.
+func (p *parser) declareVar(obj *ast.Object, pos token.Pos) {
+	if p.varPos == nil {
+		p.varPos = make(map[*ast.Object]token.Pos)
+		p.varUsed = make(map[token.Pos]bool)
+	}
+	p.varPos[obj] = pos
+}
+
+// unusedVars lists the variables never referenced. Mixin bodies are
+// copied for every @include, so a declaration is reported only once.
+func (p *parser) unusedVars() []*ast.Ident {
+	var unused []*ast.Ident
+	seen := make(map[token.Pos]bool)
+	for _, ident := range p.vars {
+		pos := ident.Pos()
+		if seen[pos] || p.varUsed[pos] {
+			continue
+		}
+		seen[pos] = true
+		unused = append(unused, ident)
+	}
+	return unused
+}
+
 // The unresolved object is a sentinel to mark identifiers that have been added
 // to the list of unresolved identifiers. The sentinel is only used for verifying
 // internal consistency.
@@ -354,6 +471,9 @@ func (p *parser) tryResolve(x ast.Expr, collectUnresolved bool) {
 		}
 		if obj := s.Lookup(ident.Name); obj != nil {
 			ident.Obj = obj
+			if pos, ok := p.varPos[obj]; ok {
+				p.varUsed[pos] = true
+			}
 			return
 		}
 	}
@@ -434,13 +554,13 @@ func (p *parser) next0() {
 	// with queueing logic to prevent any un(trace()) calls from
 	// from the parent file being executed after the sub-file parser
 	// is running.
-	if p.queue != nil {
+	if len(p.queue) > 0 {
 		err := p.pop()
 		if err != nil {
 			p.error(p.pos, fmt.Sprintf("error reading queue: %s", err))
 		}
 	}
-	p.pos, p.tok, p.lit = p.scanner.Scan()
+	p.pos, p.tok, p.lit = p.scan()
 	// end of declaration, check queue and swap scanner
 
 	// If we have encountered EOF, check the importStack before returning
@@ -452,11 +572,15 @@ func (p *parser) next0() {
 			pop, p.imps, p.imps[last] = p.imps[last], p.imps[:last], stack{}
 			p.file = pop.file
 			p.scanner = pop.scanner
+			p.replay = pop.replay
+			p.record = pop.record
 			p.pos = pop.pos
 			p.tok = pop.tok
 			p.lit = pop.lit
 			p.syncPos = pop.syncPos
 			p.syncCnt = pop.syncCnt
+			// the rest of an import list is read before moving on
+			p.queue = pop.queue
 			p.next()
 		}
 	}
@@ -762,7 +886,13 @@ func (p *parser) resolveCall(x ast.Expr) (ast.Expr, error) {
 		for i := range v.Args {
 			p.resolveExpr(p.topScope, v.Args[i])
 		}
-		return evaluateCall(p, p.topScope, v)
+		res, err := evaluateCall(p, p.topScope, v)
+		if err == nil {
+			// calls resolving to lists and maps are passed along
+			// to the calls they are arguments of
+			v.Resolved = res
+		}
+		return res, err
 	case *ast.BinaryExpr:
 		l, err := p.resolveCall(v.X)
 		if err != nil {
@@ -774,11 +904,13 @@ func (p *parser) resolveCall(x ast.Expr) (ast.Expr, error) {
 		}
 		v.X, v.Y = l, r
 		x = v
-		// Never called
-	// case *ast.UnaryExpr:
-	// 	l, _ := p.resolveCall(v.X)
-	// 	v.X = l
-	// 	x = v
+	case *ast.UnaryExpr:
+		l, err := p.resolveCall(v.X)
+		if err != nil {
+			return nil, err
+		}
+		v.X = l
+		x = v
 	case *ast.Ident:
 		if v.Obj == nil {
 			p.resolve(x)
@@ -802,12 +934,16 @@ func (p *parser) resolveInterp(scope *ast.Scope, itp *ast.Interp) {
 		var err error
 		// performing calc
 		x, err = p.resolveCall(x)
-		if err != nil {
+		if err != nil && !p.inMixin {
 			p.error(x.Pos(), "failed to resolve call: "+err.Error())
 		}
-		res, err := calc.Resolve(x, true)
+		res, err := calc.Resolve(x, true, p.env.Precision)
 		if err != nil {
-			p.error(x.Pos(), err.Error())
+			// mixin arguments are only known once it is
+			// included, the copy is resolved then
+			if !p.inMixin {
+				p.error(x.Pos(), err.Error())
+			}
 			continue
 		}
 		if res.Kind != token.STRING {
@@ -907,13 +1043,20 @@ func (p *parser) parseSassList(lhs, canComma bool) (list []ast.Expr, hasComma, c
 	if p.trace {
 		defer un(trace(p, "SassList"))
 	}
+	var lparen token.Pos
 	if p.tok == token.LPAREN {
 		checkParen = true
+		lparen = p.pos
 		p.next()
 	}
 	if p.tok == token.RULE {
-		p.error(p.pos, "sass can not contain a list")
-		p.next()
+		if !checkParen {
+			p.error(p.pos, "sass can not contain a list")
+			p.next()
+		} else {
+			list = append(list, p.parseMapLit(lhs, lparen))
+			return
+		}
 	}
 	for p.tok != token.SEMICOLON &&
 		// possible closers
@@ -946,24 +1089,73 @@ func (p *parser) parseSassList(lhs, canComma bool) (list []ast.Expr, hasComma, c
 		p.error(p.pos, "EOF reached before list end")
 	}
 	if checkParen {
-		p.expect(token.RPAREN)
+		rparen := p.expect(token.RPAREN)
+		// () is an empty list, also used as an empty map
+		if len(list) == 0 {
+			list = append(list, &ast.ListLit{
+				Paren:    true,
+				ValuePos: lparen,
+				EndPos:   rparen + 1,
+			})
+		}
 	}
 	return
 
 }
 
+// parseMapLit parses the entries of a map, lparen has already
+// been consumed
+func (p *parser) parseMapLit(lhs bool, lparen token.Pos) *ast.MapLit {
+	if p.trace {
+		defer un(trace(p, "MapLit"))
+	}
+	m := &ast.MapLit{Lparen: lparen}
+	for p.tok != token.RPAREN && p.tok != token.EOF {
+		key := &ast.BasicLit{
+			ValuePos: p.pos,
+			Kind:     token.STRING,
+			Value:    p.lit,
+		}
+		p.expect(token.RULE)
+		colon := p.expect(token.COLON)
+		val := p.listFromExprs(p.parseSassList(lhs, false))
+		if val == nil {
+			p.errorExpected(p.pos, "map value")
+			break
+		}
+		m.Value = append(m.Value, &ast.KeyValueExpr{
+			Key:   key,
+			Colon: colon,
+			Value: val,
+		})
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+	m.Rparen = p.expect(token.RPAREN)
+	return m
+}
+
 func (p *parser) expandList(in []ast.Expr) []ast.Expr {
 
 	if len(in) != 1 {
 		return in
 	}
 
+	// maps are lists of key value pairs
+	if m, ok := in[0].(*ast.MapLit); ok {
+		return mapPairs(m)
+	}
+
 	ident, ok := in[0].(*ast.Ident)
 	if !ok {
 		return in
 	}
 
-	p.tryResolve(ident, false)
+	if ident.Obj == nil {
+		p.tryResolve(ident, false)
+	}
 	if ident.Obj == nil {
 		// uninitialized variable
 		return in
@@ -974,12 +1166,27 @@ func (p *parser) expandList(in []ast.Expr) []ast.Expr {
 		return in
 	}
 
-	list, ok := ass.Rhs[0].(*ast.ListLit)
-	if !ok {
-		return in
+	switch v := ass.Rhs[0].(type) {
+	case *ast.ListLit:
+		return v.Value
+	case *ast.MapLit:
+		return mapPairs(v)
+	}
+	return in
+}
+
+// mapPairs returns the entries of a map as space delimited lists
+// of key and value
+func mapPairs(m *ast.MapLit) []ast.Expr {
+	pairs := make([]ast.Expr, len(m.Value))
+	for i, kv := range m.Value {
+		pairs[i] = &ast.ListLit{
+			ValuePos: kv.Key.Pos(),
+			EndPos:   kv.Value.End(),
+			Value:    []ast.Expr{kv.Key, kv.Value},
+		}
 	}
-
-	return list.Value
+	return pairs
 }
 
 func (p *parser) inferLhsList() ast.Expr {
@@ -1067,8 +1274,9 @@ func (p *parser) mergeInterps(in []ast.Expr) []ast.Expr {
 		itp, isInterp := in[i].(*ast.Interp)
 		if !isInterp {
 			lit, ok := in[i].(*ast.BasicLit)
-			// lookbehind if this is a candidate for merge
-			if ok && len(out) > 0 {
+			// lookbehind if this is a candidate for merge, flags
+			// are never merged ie. 1px!important
+			if ok && len(out) > 0 && lit.Kind != token.IMPORTANT {
 				l := in[i-1]
 				if l.End() == lit.Pos() {
 					prev, ok := out[len(out)-1].(*ast.Interp)
@@ -1159,10 +1367,15 @@ func (p *parser) listFromExprs(in []ast.Expr, hasComma, inParen bool) ast.Expr {
 			Comma:    hasComma,
 		}
 	}
+	if m, ok := in[0].(*ast.MapLit); ok {
+		return m
+	}
 	l, ok := in[0].(*ast.ListLit)
 	if ok {
 		// non-paren list inside paren list
-		l.Paren = true
+		if inParen {
+			l.Paren = true
+		}
 		return l
 	}
 	if inParen {
@@ -1191,13 +1404,20 @@ func (p *parser) parseString() *ast.StringExpr {
 	}
 	p.next()
 	var list []ast.Expr
+	p.inString++
 	// Only strings and interpolations allowed here
 	for p.tok != token.EOF && p.tok != tok {
 		x := p.inferExpr(false, false)
 		list = append(list, x)
 	}
+	p.inString--
 	rquote := p.expectClosing(tok, "string list")
 	expr.List = p.mergeInterps(list)
+	for _, x := range expr.List {
+		if lit, ok := x.(*ast.BasicLit); ok {
+			lit.Value = strops.UnescapeQuotes(lit.Value)
+		}
+	}
 	expr.Rquote = rquote
 	return expr
 }
@@ -1323,7 +1543,23 @@ func (p *parser) tryVarType(isParam bool) ast.Expr {
 		if p.tok == token.COLON {
 			// Default arg found!
 			pos := p.expect(token.COLON)
-			val := p.tryIdentOrType()
+			var val ast.Expr
+			switch p.tok {
+			case token.LPAREN:
+				// map or list literal
+				val = p.listFromExprs(p.parseSassList(false, true))
+			case token.ADD, token.SUB:
+				// signed number ie. $amount: -10%
+				sign, signPos := p.tok, p.pos
+				p.next()
+				val = p.tryIdentOrType()
+				if lit, ok := val.(*ast.BasicLit); ok && sign == token.SUB {
+					lit.ValuePos = signPos
+					lit.Value = "-" + lit.Value
+				}
+			default:
+				val = p.tryIdentOrType()
+			}
 			return &ast.KeyValueExpr{
 				Key:   typ,
 				Colon: pos,
@@ -1572,6 +1808,101 @@ func (p *parser) parseMediaStmt() *ast.MediaStmt {
 	}
 }
 
+// parseAtRuleStmt parses at-rules that are passed through to CSS
+// ie. @supports (display: grid) { ... }
+func (p *parser) parseAtRuleStmt() *ast.AtRuleStmt {
+	if p.trace {
+		defer un(trace(p, "AtRuleStmt"))
+	}
+
+	pos, name := p.pos, p.lit
+	p.expect(token.ATRULE)
+	stmt := &ast.AtRuleStmt{
+		AtPos: pos,
+		Name:  name,
+		Rule: &ast.BasicLit{
+			Kind:     token.STRING,
+			Value:    name,
+			ValuePos: pos,
+		},
+	}
+	if p.tok == token.STRING {
+		stmt.Rule.Value += " " + p.lit
+		p.next()
+	}
+	if p.tok != token.LBRACE {
+		p.expectSemi()
+		return stmt
+	}
+
+	// keyframe selectors ie. from, 50% never nest in the
+	// parent selector
+	if strings.HasSuffix(name, "keyframes") {
+		sels := p.sels
+		p.sels = nil
+		defer func() { p.sels = sels }()
+	}
+	stmt.Body = p.parseBody(p.topScope)
+	return stmt
+}
+
+// parseAtRootStmt parses @at-root, the rules inside are not nested in
+// the parent selectors ie. @at-root .a { ... } or
+// @at-root (without: media) { ... }
+func (p *parser) parseAtRootStmt() *ast.AtRootStmt {
+	if p.trace {
+		defer un(trace(p, "AtRootStmt"))
+	}
+
+	stmt := &ast.AtRootStmt{AtRoot: p.expect(token.ATROOT)}
+	if p.tok == token.LPAREN {
+		stmt.Query = p.parseAtRootQuery()
+	}
+	if stmt.Excludes("rule") {
+		sels, root := p.sels, p.rootSel
+		if len(sels) > 0 {
+			p.rootSel = sels[len(sels)-1]
+		}
+		p.sels = nil
+		defer func() { p.sels, p.rootSel = sels, root }()
+	}
+	if p.tok == token.SELECTOR {
+		sel := p.parseSelStmt(false)
+		stmt.Body = &ast.BlockStmt{
+			Lbrace: sel.Pos(),
+			List:   []ast.Stmt{sel},
+			Rbrace: sel.End(),
+		}
+		return stmt
+	}
+	stmt.Body = p.parseBody(p.topScope)
+	return stmt
+}
+
+// parseAtRootQuery collects the query of @at-root ie. (without: media)
+func (p *parser) parseAtRootQuery() *ast.BasicLit {
+	lit := &ast.BasicLit{
+		Kind:     token.STRING,
+		ValuePos: p.expect(token.LPAREN),
+	}
+	s := "("
+	for p.tok != token.RPAREN && p.tok != token.LBRACE &&
+		p.tok != token.EOF {
+		if p.tok == token.COLON {
+			s += ": "
+		} else {
+			if len(s) > 1 && !strings.HasSuffix(s, " ") {
+				s += " "
+			}
+			s += p.lit
+		}
+		p.next()
+	}
+	p.expect(token.RPAREN)
+	lit.Value = s + ")"
+	return lit
+}
+
 // parseOperand may return an expression or a raw type (incl. array
 // types of the form [...]T. Callers must verify the result.
 // If lhs is set and the result is an identifier, it is not resolved.
@@ -1606,17 +1937,24 @@ func (p *parser) parseOperand(lhs bool) ast.Expr {
 		p.expect(tok)
 		return x
 	case
-		token.COLOR,
+		token.COLOR, token.NULL, token.BOOL,
 		token.UEM, token.UPCT, token.UPT, token.UPX, token.UREM,
 		token.INT, token.FLOAT, token.STRING:
 		x := &ast.BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit}
+		if x.Kind == token.STRING && p.inString == 0 {
+			// CSS color names participate in color math
+			if _, ok := ast.LookupColorName(x.Value); ok {
+				x.Kind = token.COLOR
+			}
+		}
 		p.next()
 		return x
 
 	case token.LPAREN:
-		ls, _, _ := p.parseSassList(lhs, true)
+		ls, hasComma, _ := p.parseSassList(lhs, true)
 		if len(ls) > 1 {
-			panic("multiple lists found")
+			// comma separated list ie. (1, 2)
+			return p.listFromExprs(ls, hasComma, true)
 		}
 
 		return ls[0]
@@ -1700,12 +2038,24 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	lparen := p.expect(token.LPAREN)
 	p.exprLev++
 	var list []ast.Expr
-	expr := p.inferExprList(false)
-	lit, ok := expr.(*ast.ListLit)
-	if ok {
-		list = lit.Value
-	} else if expr != nil {
-		list = []ast.Expr{expr}
+	for {
+		expr := p.inferExprList(false)
+		lit, ok := expr.(*ast.ListLit)
+		if ok && lit.Paren {
+			expr = p.parseParenTail(expr)
+		}
+		if ok && lit.Comma && !lit.Paren {
+			list = append(list, lit.Value...)
+		} else if expr != nil {
+			// a single list is one argument ie. length(1px 2px)
+			list = append(list, expr)
+		}
+		// a parenthesized list ends the arguments early
+		// ie. nth((a, b), 1)
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
 	}
 
 	p.exprLev--
@@ -1723,7 +2073,7 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	if !ok {
 		log.Fatalf("% #v\n", fun)
 	}
-	if p.mode&FuncOnly == 0 {
+	if p.mode&FuncOnly == 0 && p.inEach == 0 && !p.inMixin {
 		lit, err := evaluateCall(p, p.topScope, call)
 		call.Resolved = lit
 		// Manually set object, because Ident name isn't unique
@@ -1737,6 +2087,25 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	return call
 }
 
+// parseParenTail continues an argument that began with a parenthesized
+// list ie. calc((100% - 10px) / 3)
+func (p *parser) parseParenTail(x ast.Expr) ast.Expr {
+	for {
+		op, prec := p.tokPrec()
+		if prec <= token.LowestPrec {
+			return x
+		}
+		pos := p.expect(op)
+		y := p.parseBinaryExpr(false, false, prec+1)
+		x = &ast.BinaryExpr{
+			X:     x,
+			OpPos: pos,
+			Op:    op,
+			Y:     p.checkExpr(y),
+		}
+	}
+}
+
 func (p *parser) parseValue(keyOk bool) ast.Expr {
 	if p.trace {
 		defer un(trace(p, "Element"))
@@ -1981,6 +2350,10 @@ func (p *parser) tokPrec() (token.Token, int) {
 	if p.inRhs && tok == token.ASSIGN {
 		tok = token.EQL
 	}
+	// a signed operand starts the next item of a list, see scanner
+	if tok == token.SUB && p.lit == "-" {
+		return tok, token.LowestPrec
+	}
 	return tok, tok.Precedence()
 }
 
@@ -2124,6 +2497,23 @@ func (p *parser) parseReturnStmt() *ast.ReturnStmt {
 	return &ast.ReturnStmt{Return: pos, Results: x}
 }
 
+// parseDebugStmt parses @debug, @warn and @error
+func (p *parser) parseDebugStmt() *ast.DebugStmt {
+	if p.trace {
+		defer un(trace(p, "DebugStmt"))
+	}
+
+	stmt := &ast.DebugStmt{TokPos: p.pos, Tok: p.tok}
+	p.next()
+	stmt.X = p.listFromExprs(p.parseSassList(false, true))
+	if stmt.X == nil {
+		p.errorExpected(p.pos, "expression")
+		stmt.X = &ast.BadExpr{From: p.pos, To: p.pos}
+	}
+	p.expectSemi()
+	return stmt
+}
+
 func (p *parser) makeExpr(s ast.Stmt, kind string) ast.Expr {
 	if s == nil {
 		return nil
@@ -2178,30 +2568,20 @@ func (p *parser) resolveIfStmt(scope *ast.Scope, in *ast.IfStmt) []ast.Stmt {
 	fmt.Println("resolve ifstmt!")
 	var ret []ast.Stmt
 	decl := in
-	cond := in.Cond
-	// TODO: This is weird, but it resolves idents
-	p.resolveExpr(scope, cond)
-
-	// Check if this points to a binary expr, otherwise
-	// compare with false (and maybe nil?)
-	var compFalse bool
-	if ident, ok := cond.(*ast.Ident); ok {
-		if _, ok := ident.Obj.Decl.(*ast.BinaryExpr); !ok {
-			compFalse = true
-		}
+	// resolves idents and calls within the condition
+	cond, err := p.resolveCall(in.Cond)
+	if err != nil {
+		panic(fmt.Sprint("failed to understand condition: ", err))
 	}
 
-	fmt.Printf("cond % #v\n", decl.Cond)
-	lit, err := calc.Resolve(decl.Cond, true)
+	fmt.Printf("cond % #v\n", cond)
+	lit, err := calc.Resolve(cond, true, p.env.Precision)
 	if err != nil {
 		panic(fmt.Sprint("failed to understand condition: ", err))
 	}
 	switch {
-	// This checks just for presence of a variable
-	case compFalse && lit.Value != "false":
-		fmt.Println("compfalse", lit.Value)
-		fallthrough
-	case lit.Value == "true":
+	// false and null are the only falsey values
+	case calc.Truthy(lit):
 		fmt.Println("true...")
 		resList := p.resolveStmts(scope, decl.Body.List)
 		ret = append(ret, resList...)
@@ -2243,6 +2623,11 @@ func (p *parser) parseEachStmt() *ast.EachStmt {
 	pos := p.expect(token.EACH)
 	// each variable iterator
 	itr := p.parseVarType(true).(*ast.Ident)
+	var val *ast.Ident
+	if p.tok == token.COMMA {
+		p.next()
+		val = p.parseVarType(true).(*ast.Ident)
+	}
 
 	// in
 	if p.lit != "in" {
@@ -2253,68 +2638,64 @@ func (p *parser) parseEachStmt() *ast.EachStmt {
 
 	list, _, _ := p.parseSassList(true, false)
 
+	// The iterators are not known until the body is resolved
+	p.inEach++
 	body := p.parseBody(p.topScope)
+	p.inEach--
 	each := &ast.EachStmt{
-		Each: pos,
-		X:    itr,
-		List: list,
-		Body: body,
+		Each:  pos,
+		X:     itr,
+		Value: val,
+		List:  list,
+		Body:  body,
 	}
 
 	// FIXME: decide when to resolve the each stmt
-	if !p.inMixin {
+	if !p.inMixin && p.inEach == 0 {
 		p.resolveEachStmt(p.topScope, each)
 	}
 	return each
 }
 
-func (p *parser) resolveEachStmt(outscope *ast.Scope, each *ast.EachStmt) {
-	litsToExprs := func(in []*ast.BasicLit) []ast.Expr {
-		out := make([]ast.Expr, len(in))
-		for i := range in {
-			out[i] = in[i]
-		}
-		return out
+// declareEach assigns the resolved value x to the iterator itr in scope
+func (p *parser) declareEach(outscope, scope *ast.Scope, itr *ast.Ident, x ast.Expr) {
+	lits := p.resolveExpr(outscope, x)
+	rhs := make([]ast.Expr, len(lits))
+	for i := range lits {
+		rhs[i] = lits[i]
 	}
-
-	// attempt expansion of $var in $vars
-	list := p.expandList(each.List)
-	itrName := each.X.Name
-	r := ast.NewIdent(itrName)
-
+	r := ast.NewIdent(itr.Name)
 	// at some point, all decl are enforced as AssignStmt
 	ass := &ast.AssignStmt{
 		Lhs:    []ast.Expr{r},
-		TokPos: list[0].Pos(),
-		Rhs:    litsToExprs(p.resolveExpr(outscope, list[0])),
+		TokPos: x.Pos(),
+		Rhs:    rhs,
 	}
+	p.declare(ass, nil, scope, ast.Var, r)
+}
+
+func (p *parser) resolveEachStmt(outscope *ast.Scope, each *ast.EachStmt) {
+	// attempt expansion of $var in $vars
+	list := p.expandList(each.List)
 
 	var stmts []ast.Stmt
-	// walk through first iterator
-	scope := ast.NewScope(outscope)
-	p.declare(ass, nil, scope, ast.Var, r)
-	copy := make([]ast.Stmt, len(each.Body.List))
-	for i := range each.Body.List {
-		copy[i] = ast.StmtCopy(each.Body.List[i])
-	}
-	stmts = append(stmts, p.resolveStmts(scope, copy)...)
+	for _, l := range list {
+		scope := ast.NewScope(outscope)
+		if each.Value == nil {
+			p.declareEach(outscope, scope, each.X, l)
+		} else if pair, ok := l.(*ast.ListLit); ok && len(pair.Value) > 1 {
+			// Map entries and lists of lists are destructured
+			p.declareEach(outscope, scope, each.X, pair.Value[0])
+			p.declareEach(outscope, scope, each.Value, pair.Value[1])
+		} else {
+			p.declareEach(outscope, scope, each.X, l)
+		}
 
-	for _, l := range list[1:] {
-		// Copy the body from list 1
+		// Copy the body for each iteration
 		copy := make([]ast.Stmt, len(each.Body.List))
 		for i := range each.Body.List {
 			copy[i] = ast.StmtCopy(each.Body.List[i])
 		}
-
-		r := ast.NewIdent(itrName)
-		// at some point, all decl are enforced as AssignStmt
-		ass := &ast.AssignStmt{
-			Lhs:    []ast.Expr{r},
-			TokPos: list[0].Pos(),
-			Rhs:    litsToExprs(p.resolveExpr(scope, l)),
-		}
-		scope := ast.NewScope(outscope)
-		p.declare(ass, nil, scope, ast.Var, r)
 		stmts = append(stmts, p.resolveStmts(scope, copy)...)
 	}
 	// Modify body with new stmts
@@ -2407,7 +2788,7 @@ func (p *parser) parseStmt() (s ast.Stmt, isSelector bool) {
 	}
 
 	switch p.tok {
-	case token.IDENT, token.RULE:
+	case token.IDENT, token.RULE, token.INTERP:
 		s = &ast.DeclStmt{Decl: p.parseDecl(syncStmt)}
 		// p.expectSemi()
 	case token.COMMENT:
@@ -2446,11 +2827,25 @@ func (p *parser) parseStmt() (s ast.Stmt, isSelector bool) {
 		s = p.parseReturnStmt()
 	case token.MEDIA:
 		s = p.parseMediaStmt()
+	case token.ATRULE:
+		s = p.parseAtRuleStmt()
+	case token.ATROOT:
+		s = p.parseAtRootStmt()
+	case token.EXTEND:
+		s = p.parseExtendStmt()
+	case token.DEBUG, token.WARN, token.ERROR:
+		s = p.parseDebugStmt()
 	case token.LBRACE:
 		s = p.parseBlockStmt()
 		p.expectSemi()
 	case token.IF:
 		s = p.parseIfStmt()
+	case token.ELSE, token.ELSEIF:
+		// @else is consumed by parseIfStmt, any other is orphaned
+		pos := p.pos
+		p.error(pos, "@else must follow @if")
+		syncStmt(p)
+		s = &ast.BadStmt{From: pos, To: p.pos}
 	case token.FOR:
 		s = p.parseForStmt()
 	case token.IMPORT:
@@ -2496,7 +2891,7 @@ func isValidImport(lit string) bool {
 	return s != ""
 }
 
-func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ token.Token, _ int) ast.Spec {
+func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ token.Token, iota int) ast.Spec {
 	if p.trace {
 		// defer un(trace(p, "ImportSpec"))
 	}
@@ -2510,11 +2905,20 @@ func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ token.Token, _ int) as
 		ident = p.parseIdent()
 	}
 
-	p.expect(token.IMPORT)
-	x := p.parseOperand(false)
-	pathlit, ok := x.(*ast.BasicLit)
-	if !ok {
-		p.errorExpected(x.Pos(), "expected import to be string or quoted string")
+	// only the first import of a list follows @import
+	if iota == 0 {
+		p.expect(token.IMPORT)
+	}
+	var pathlit *ast.BasicLit
+	if p.tok == token.IDENT && p.lit == "url" {
+		pathlit = p.parseImportURL()
+	} else {
+		x := p.parseOperand(false)
+		var ok bool
+		pathlit, ok = x.(*ast.BasicLit)
+		if !ok {
+			p.errorExpected(x.Pos(), "expected import to be string or quoted string")
+		}
 	}
 
 	// collect imports
@@ -2522,17 +2926,113 @@ func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ token.Token, _ int) as
 		// Doc:     doc,
 		Name:    ident,
 		Path:    pathlit,
+		Media:   p.parseImportMedia(),
 		Comment: p.lineComment,
 	}
-	// Parse and insert the results into the current parser
 	p.imports = append(p.imports, spec)
-	err := p.processImport(spec.Path.Value)
-	if err != nil {
-		log.Fatalf("failed to import: %s", spec.Name)
+	// CSS imports are not processed, they are output as is
+	spec.CSS = isCSSImport(spec)
+	// Sass imports are read once the list ends ie. @import "a", "b";
+	if p.tok == token.COMMA {
+		return spec
+	}
+	for _, imp := range p.imports[len(p.imports)-1-iota:] {
+		if imp.CSS {
+			continue
+		}
+		// Parse and insert the results into the current parser
+		err := p.processImport(imp.Path.Value)
+		if err != nil {
+			log.Fatalf("failed to import: %s", imp.Name)
+		}
 	}
 	return spec
 }
 
+// parseImportURL parses url(path) as an unquoted literal including
+// the url() wrapper, ie. url("foo.css")
+func (p *parser) parseImportURL() *ast.BasicLit {
+	pos := p.pos
+	p.next()
+	p.expect(token.LPAREN)
+	s := "url("
+	for p.tok != token.RPAREN && p.tok != token.SEMICOLON &&
+		p.tok != token.EOF {
+		switch p.tok {
+		case token.QSTRING:
+			s += `"`
+		case token.QSSTRING:
+			s += "'"
+		default:
+			s += p.lit
+		}
+		p.next()
+	}
+	p.expect(token.RPAREN)
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		Value:    s + ")",
+		ValuePos: pos,
+	}
+}
+
+// parseImportMedia collects the media query following an import path
+// ie. @import "foo" screen and (orientation: landscape); A comma
+// directly after the path starts the next import ie. @import "a", "b";
+func (p *parser) parseImportMedia() *ast.BasicLit {
+	pos := p.pos
+	var s, last string
+	for p.tok != token.SEMICOLON && p.tok != token.EOF {
+		if p.tok == token.COMMA && len(s) == 0 {
+			break
+		}
+		// paths can not follow a media query ie. "a" print, "b"
+		if p.tok == token.QSTRING || p.tok == token.QSSTRING ||
+			strings.HasPrefix(p.lit, "url(") {
+			p.errorExpected(p.pos, "media query")
+			break
+		}
+		lit := p.lit
+		if len(lit) == 0 {
+			lit = p.tok.String()
+		}
+		switch {
+		case len(s) == 0, last == "(", p.tok == token.RPAREN,
+			p.tok == token.COLON, p.tok == token.COMMA:
+		default:
+			s += " "
+		}
+		s += lit
+		last = lit
+		p.next()
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	return &ast.BasicLit{
+		Kind:     token.STRING,
+		Value:    s,
+		ValuePos: pos,
+	}
+}
+
+// isCSSImport reports whether spec is output as a CSS @import rather
+// than inlined. Imports of .css files, urls, remote files or imports
+// with a media query are CSS imports.
+func isCSSImport(spec *ast.ImportSpec) bool {
+	path := spec.Path.Value
+	switch {
+	case spec.Media != nil:
+		return true
+	case strings.HasPrefix(path, "url("),
+		strings.HasPrefix(path, "http://"),
+		strings.HasPrefix(path, "https://"),
+		strings.HasPrefix(path, "//"):
+		return true
+	}
+	return strings.HasSuffix(path, ".css")
+}
+
 func (p *parser) processImport(path string) error {
 	return p.add(path, nil)
 }
@@ -2556,7 +3056,7 @@ func checkForGlobal(vals []ast.Expr) bool {
 	}
 
 	if len(vals) < 2 {
-		if list, ok := vals[0].(*ast.ListLit); ok {
+		if list, ok := vals[0].(*ast.ListLit); ok && len(list.Value) > 0 {
 			vals = list.Value
 		} else {
 			return false
@@ -2573,6 +3073,70 @@ func checkForGlobal(vals []ast.Expr) bool {
 	return false
 }
 
+// checkForDefault removes the !default flag from the values of a
+// variable declaration and reports whether it was found
+func checkForDefault(vals []ast.Expr) ([]ast.Expr, bool) {
+	if len(vals) != 1 {
+		return vals, false
+	}
+	x, ok := stripFlag(vals[0], "!default")
+	if !ok {
+		return vals, false
+	}
+	return []ast.Expr{x}, true
+}
+
+// stripFlag removes flag from the end of the list x and reports
+// whether it was found. In comma lists the flag ends the last element
+// ie. a, b !default
+func stripFlag(x ast.Expr, flag string) (ast.Expr, bool) {
+	list, ok := x.(*ast.ListLit)
+	if !ok || len(list.Value) == 0 {
+		return x, false
+	}
+	n := len(list.Value)
+	// the list may be the value of another variable, copy it
+	cpy := *list
+	if list.Comma {
+		last, ok := stripFlag(list.Value[n-1], flag)
+		if !ok {
+			return x, false
+		}
+		cpy.Value = append(list.Value[:n-1:n-1], last)
+		return &cpy, true
+	}
+	lit, ok := list.Value[n-1].(*ast.BasicLit)
+	if n < 2 || !ok || lit.Kind != token.STRING || lit.Value != flag {
+		return x, false
+	}
+	if n == 2 {
+		return list.Value[0], true
+	}
+	cpy.Value = list.Value[: n-1 : n-1]
+	return &cpy, true
+}
+
+// lookupDefault returns the variable name when it is declared in the
+// current scope or any of its outer scopes and is not null, a !default
+// declaration of name does not change its value.
+func (p *parser) lookupDefault(name string) *ast.Object {
+	for s := p.topScope; s != nil; s = s.Outer {
+		obj := s.Lookup(name)
+		if obj == nil {
+			continue
+		}
+		assign, ok := obj.Decl.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			return obj
+		}
+		if lit, ok := assign.Rhs[0].(*ast.BasicLit); ok && lit.Kind == token.NULL {
+			return nil
+		}
+		return obj
+	}
+	return nil
+}
+
 func (p *parser) inferValueSpec(doc *ast.CommentGroup, keyword token.Token, iota int) ast.Spec {
 	if p.trace {
 		defer un(trace(p, "inferValue"+keyword.String()+"Spec"))
@@ -2586,9 +3150,16 @@ func (p *parser) inferValueSpec(doc *ast.CommentGroup, keyword token.Token, iota
 		return p.parseIncludeSpec(!p.inMixin)
 	}
 
-	name := &ast.Ident{
-		Name:    lit,
-		NamePos: p.pos,
+	var name *ast.Ident
+	switch keyword {
+	case token.RULE, token.INTERP:
+		name = p.parseRuleName()
+	default:
+		name = &ast.Ident{
+			Name:    lit,
+			NamePos: p.pos,
+		}
+		p.next()
 	}
 
 	// Type has to be derived from the values being set
@@ -2596,7 +3167,6 @@ func (p *parser) inferValueSpec(doc *ast.CommentGroup, keyword token.Token, iota
 	// var typ ast.Expr
 	var values []ast.Expr
 	lhs := true
-	p.next()
 	pos, tok := p.pos, p.tok
 	switch p.tok {
 	case token.LPAREN:
@@ -2608,10 +3178,27 @@ func (p *parser) inferValueSpec(doc *ast.CommentGroup, keyword token.Token, iota
 		fallthrough
 	default:
 		x := p.inferExprList(lhs)
-		if p.tok == token.SEMICOLON {
+		// the last declaration in a block may omit ; ie. a{b:c}
+		if p.tok == token.SEMICOLON || p.tok == token.RBRACE {
 			values = append(values, x)
 			break
 		}
+		// comparisons against a list ie. (1 2) == $list
+		switch p.tok {
+		case token.EQL, token.NEQ, token.LSS, token.GTR, token.LEQ,
+			token.GEQ, token.LAND, token.LOR:
+			pos, op := p.pos, p.tok
+			p.next()
+			values = append(values, &ast.BinaryExpr{
+				X:     x,
+				OpPos: pos,
+				Op:    op,
+				Y:     p.inferExprList(false),
+			})
+		}
+		if len(values) > 0 {
+			break
+		}
 		// check for string math against a list...
 		y := p.parseUnaryExpr(false)
 		if un, ok := y.(*ast.UnaryExpr); ok {
@@ -2640,7 +3227,23 @@ func (p *parser) inferValueSpec(doc *ast.CommentGroup, keyword token.Token, iota
 
 	switch keyword {
 	case token.VAR:
+		var isDefault bool
+		values, isDefault = checkForDefault(values)
 		name.Global = checkForGlobal(values)
+		if isDefault {
+			if obj := p.lookupDefault(name.Name); obj != nil {
+				// the variable keeps its value
+				name.Obj = obj
+				if assign, ok := obj.Decl.(*ast.AssignStmt); ok {
+					values = assign.Rhs
+				}
+				return &ast.ValueSpec{
+					Names:   []*ast.Ident{name},
+					Comment: p.lineComment,
+					Values:  values,
+				}
+			}
+		}
 		// Assignment happening
 		spec = &ast.ValueSpec{
 			// Doc:   doc,
@@ -2666,6 +3269,34 @@ func (p *parser) inferValueSpec(doc *ast.CommentGroup, keyword token.Token, iota
 
 }
 
+// parseRuleName merges adjacent text and interpolations found in
+// the name of a rule ie. margin-#{$side}-top
+func (p *parser) parseRuleName() *ast.Ident {
+	if p.trace {
+		defer un(trace(p, "RuleName"))
+	}
+	name := &ast.Ident{NamePos: p.pos}
+	end := p.pos
+	for p.pos == end {
+		switch p.tok {
+		case token.INTERP:
+			itp := p.parseInterp()
+			p.resolveInterp(p.topScope, itp)
+			if itp.Obj != nil {
+				name.Name += itp.Obj.Decl.(*ast.BasicLit).Value
+			}
+			end = itp.End()
+		case token.RULE, token.STRING:
+			name.Name += p.lit
+			end = p.pos + token.Pos(len(p.lit))
+			p.next()
+		default:
+			return name
+		}
+	}
+	return name
+}
+
 func (p *parser) parseGenDecl(lit string, keyword token.Token, f parseSpecFunction) *ast.GenDecl {
 	if p.trace {
 		defer un(trace(p, "GenDecl("+keyword.String()+")"))
@@ -2686,8 +3317,20 @@ func (p *parser) parseGenDecl(lit string, keyword token.Token, f parseSpecFuncti
 		rparen = p.expect(token.RPAREN)
 	} else {
 		list = append(list, f(nil, keyword, 0))
+		// imports are a comma separated list ie. @import "a", "b";
+		for keyword == token.IMPORT && p.tok == token.COMMA {
+			p.next()
+			list = append(list, f(nil, keyword, len(list)))
+		}
+	}
+	// content blocks ie. @include foo { } end the declaration
+	var spec ast.Spec
+	if len(list) > 0 {
+		spec = list[len(list)-1]
+	}
+	if inc, ok := spec.(*ast.IncludeSpec); !ok || inc.Body == nil {
+		p.expectSemi()
 	}
-	p.expectSemi()
 
 	return &ast.GenDecl{
 		// Doc:    doc,
@@ -2728,6 +3371,10 @@ func (p *parser) parseSelStmt(backrefOk bool) *ast.SelStmt {
 
 	if len(p.sels) > 0 {
 		sel.Parent = p.sels[len(p.sels)-1]
+	} else if p.rootSel != nil && strings.Contains(lit, "&") {
+		// & inside @at-root is the parent it left ie.
+		// .a { @at-root &-x {} } is .a-x
+		sel.Parent = p.rootSel
 	}
 
 	var xs []ast.Expr
@@ -2751,11 +3398,17 @@ func (p *parser) parseSelStmt(backrefOk bool) *ast.SelStmt {
 		stmt, err := reparseSelector(s)
 		if err != nil {
 			p.error(pos, err.Error())
+		} else {
+			sel.Sel = stmt.Sel
+			sel.Resolved = stmt.Resolved
 		}
-		sel.Sel = stmt.Sel
-		sel.Resolved = stmt.Resolved
 	}
-	sel.Resolve(Globalfset)
+	if err := sel.Resolve(Globalfset); err != nil {
+		p.error(pos, err.Error())
+	}
+	if hasPlaceholder(sel.Resolved.Value) {
+		p.placeholders = true
+	}
 	p.openSelector(sel)
 	sel.Body = p.parseBody(scope)
 	p.closeSelector()
@@ -2789,42 +3442,50 @@ func (p *parser) parseSel() ast.Expr {
 	}
 
 	switch p.tok {
-	case token.AND:
-		// Backreference create a nested Op
-		// with the parent as X and child as Y
-		pos, lit := p.pos, p.lit
-		p.next()
-		return &ast.UnaryExpr{
-			OpPos: pos,
-			Op:    token.NEST,
-			X: &ast.BasicLit{
-				Kind:     token.STRING,
-				ValuePos: pos,
-				Value:    lit,
-			},
-		}
-	case token.ADD, token.GTR, token.TIL:
-		pos, op := p.pos, p.tok
-		p.next()
-		x := p.parseSel()
-		return &ast.UnaryExpr{OpPos: pos, Op: op, X: p.checkExpr(x)}
-	case token.STRING, token.ATTRIBUTE:
+	case token.AND, token.STRING, token.ATTRIBUTE, token.PSEUDO:
 		pos := p.pos
 		var lits []string
-		// eat all the strings
-		for p.tok == token.STRING || p.tok == token.ATTRIBUTE {
-			lits = append(lits, p.lit)
+		var backref bool
+		var end token.Pos
+		// eat all the strings and backreferences ie. p & or & p
+		for p.tok == token.STRING || p.tok == token.ATTRIBUTE ||
+			p.tok == token.PSEUDO || p.tok == token.AND {
+			backref = backref || p.tok == token.AND
+			// attributes and pseudo-classes directly following a
+			// selector are part of it ie. input[type]
+			if (p.tok == token.ATTRIBUTE || p.tok == token.PSEUDO) &&
+				len(lits) > 0 && p.pos == end {
+				lits[len(lits)-1] += p.lit
+			} else {
+				lits = append(lits, p.lit)
+			}
+			end = p.pos + token.Pos(len(p.lit))
 			p.next()
 		}
 		s := strings.Join(lits, " ")
 
 		// TODO: inferExpr should be creating this or the scanner
 		// should combine adjacent strings
-		return &ast.BasicLit{
+		lit := &ast.BasicLit{
 			Kind:     token.STRING,
 			Value:    s,
 			ValuePos: pos,
 		}
+		if !backref {
+			return lit
+		}
+		// Backreference create a nested Op, the parent replaces
+		// & in X
+		return &ast.UnaryExpr{
+			OpPos: pos,
+			Op:    token.NEST,
+			X:     lit,
+		}
+	case token.ADD, token.GTR, token.TIL:
+		pos, op := p.pos, p.tok
+		p.next()
+		x := p.parseSel()
+		return &ast.UnaryExpr{OpPos: pos, Op: op, X: p.checkExpr(x)}
 	case token.INTERP:
 		x := p.parseInterp()
 		p.resolveInterp(p.topScope, x)
@@ -3005,6 +3666,12 @@ func (p *parser) processFuncArgs(scope *ast.Scope, signature *ast.FieldList, arg
 				p.resolve(vv)
 				// TODO: this may need to recursively search for BasicLit
 				val = vv.Obj.Decl
+			case *ast.ListLit, *ast.MapLit:
+				val = &ast.AssignStmt{
+					Lhs:    []ast.Expr{key},
+					TokPos: vv.Pos(),
+					Rhs:    []ast.Expr{vv},
+				}
 			default:
 				log.Fatalf("unsupported default value % #v\n", vv)
 			}
@@ -3016,6 +3683,8 @@ func (p *parser) processFuncArgs(scope *ast.Scope, signature *ast.FieldList, arg
 
 	// Hold variadic arguments, saving to a list
 	var lastArg []ast.Expr
+	// keyword arguments matching no parameter, see keywords()
+	var keywords []*ast.KeyValueExpr
 
 	// Now walk through passed arguments and toDeclare finding the
 	// appropriate matching arg
@@ -3028,7 +3697,7 @@ func (p *parser) processFuncArgs(scope *ast.Scope, signature *ast.FieldList, arg
 
 			var val interface{}
 			switch v := arg.Type.(type) {
-			case *ast.BasicLit:
+			case *ast.BasicLit, *ast.StringExpr, *ast.ListLit:
 				val = &ast.AssignStmt{
 					Lhs:    []ast.Expr{ident},
 					TokPos: arg.Pos(),
@@ -3037,6 +3706,24 @@ func (p *parser) processFuncArgs(scope *ast.Scope, signature *ast.FieldList, arg
 			case *ast.Ident:
 				p.resolve(v)
 				val = v.Obj.Decl
+			case *ast.CallExpr, *ast.BinaryExpr:
+				// nested calls and math are evaluated before
+				// being passed along
+				x, err := p.resolveCall(v)
+				if err == nil {
+					if _, ok := x.(*ast.BinaryExpr); ok {
+						x, err = calc.Resolve(x, true, p.env.Precision)
+					}
+				}
+				if err != nil {
+					p.error(v.Pos(), err.Error())
+					continue
+				}
+				val = &ast.AssignStmt{
+					Lhs:    []ast.Expr{ident},
+					TokPos: arg.Pos(),
+					Rhs:    []ast.Expr{x},
+				}
 			case *ast.KeyValueExpr:
 				ident = v.Key.(*ast.Ident)
 				val = v.Value
@@ -3050,24 +3737,48 @@ func (p *parser) processFuncArgs(scope *ast.Scope, signature *ast.FieldList, arg
 				continue
 			}
 
-			if isVariadic && i >= len(sigs)-1 {
+			if isVariadic {
 				// these fucking assignstmt need to go away
 				v := val
 				if ass, ok := v.(*ast.AssignStmt); ok {
 					v = ass.Rhs[0]
 				}
-				lastArg = append(lastArg, v.(ast.Expr))
-				continue
+				if _, ok := arg.Type.(*ast.KeyValueExpr); ok && !hasIdent(sigs, ident) {
+					keywords = append(keywords, &ast.KeyValueExpr{
+						Key: &ast.BasicLit{
+							Kind:     token.STRING,
+							ValuePos: ident.Pos(),
+							Value:    strings.TrimPrefix(ident.Name, "$"),
+						},
+						Colon: ident.End(),
+						Value: v.(ast.Expr),
+					})
+					continue
+				}
+				if i >= len(sigs)-1 {
+					lastArg = append(lastArg, v.(ast.Expr))
+					continue
+				}
 			}
 			toDeclare[ident] = val
 		}
 	}
 
-	if len(lastArg) > 0 {
+	if len(lastArg) > 0 || len(keywords) > 0 {
 		ident := signature.List[len(signature.List)-1].Type.(*ast.Ident)
 		ident.Name = strings.TrimSuffix(ident.Name, "...")
 
 		list := p.listFromExprs(lastArg, true, true)
+		if len(keywords) > 0 {
+			list = &ast.ListLit{
+				ValuePos: ident.Pos(),
+				EndPos:   ident.End(),
+				Value:    lastArg,
+				Paren:    true,
+				Comma:    true,
+				Keywords: keywords,
+			}
+		}
 		ass := &ast.AssignStmt{
 			Lhs:    []ast.Expr{ident},
 			TokPos: ident.Pos(),
@@ -3088,6 +3799,16 @@ func (p *parser) processFuncArgs(scope *ast.Scope, signature *ast.FieldList, arg
 	}
 }
 
+// hasIdent reports whether an ident named like ident is in idents
+func hasIdent(idents []*ast.Ident, ident *ast.Ident) bool {
+	for _, id := range idents {
+		if id != nil && id.Name == ident.Name {
+			return true
+		}
+	}
+	return false
+}
+
 // walks through statements resolving them with the provided
 // scope
 func (p *parser) resolveStmts(scope *ast.Scope, stmts []ast.Stmt) []ast.Stmt {
@@ -3104,17 +3825,41 @@ func (p *parser) resolveStmts(scope *ast.Scope, stmts []ast.Stmt) []ast.Stmt {
 			p.resolveDecl(scope, decl)
 			stmts[i] = decl
 		case *ast.AssignStmt:
+			for i := range decl.Rhs {
+				decl.Rhs[i] = p.resolveAssign(decl.Rhs[i])
+			}
 			p.shortVarDecl(decl, decl.Lhs)
 		case *ast.CommStmt:
 		case *ast.EachStmt:
 			p.resolveEachStmt(scope, decl)
 		case *ast.IncludeStmt:
+			// content blocks found inside a mixin resolve in
+			// the scope of that mixin
+			if body := decl.Spec.Body; body != nil {
+				body.List = p.resolveStmts(scope, body.List)
+			}
 			p.resolveIncludeSpec(decl.Spec)
+		case *ast.AtRuleStmt:
+			if decl.Name == "@content" {
+				if body, _ := p.env.Content(); body != nil {
+					ret = append(ret, body.List...)
+				}
+				continue
+			}
+		case *ast.AtRootStmt:
+			sels := p.sels
+			if decl.Excludes("rule") {
+				p.sels = nil
+			}
+			decl.Body.List = p.resolveStmts(scope, decl.Body.List)
+			p.sels = sels
 		case *ast.SelStmt:
 			if len(p.sels) > 0 {
 				decl.Parent = p.sels[len(p.sels)-1]
 			}
-			decl.Resolve(Globalfset)
+			if err := decl.Resolve(Globalfset); err != nil {
+				p.error(decl.Pos(), err.Error())
+			}
 			p.openSelector(decl)
 			decl.Body.List = p.resolveStmts(scope, decl.Body.List)
 			p.closeSelector()
@@ -3126,10 +3871,33 @@ func (p *parser) resolveStmts(scope *ast.Scope, stmts []ast.Stmt) []ast.Stmt {
 			ret = append(ret, p.resolveIfStmt(scope, decl)...)
 			continue
 		case *ast.ReturnStmt:
-			// TODO: something to do here?
+			// resolve arguments and nested calls within the
+			// function scope
+			for j, x := range decl.Results {
+				res, err := p.resolveCall(x)
+				if err != nil {
+					p.error(x.Pos(), err.Error())
+					continue
+				}
+				decl.Results[j] = res
+			}
+		case *ast.ExtendStmt:
+			// @extend inside a mixin extends the including selector
+			if len(p.sels) > 0 {
+				decl.Parent = p.sels[len(p.sels)-1]
+				p.extends = append(p.extends, decl)
+			}
 		case *ast.BlockStmt:
 			list := p.resolveStmts(scope, decl.List)
 			ret = append(ret, list...)
+		case *ast.DebugStmt:
+			p.resolveInterps(scope, decl.X)
+			x, err := p.resolveCall(decl.X)
+			if err != nil {
+				p.error(decl.X.Pos(), err.Error())
+				continue
+			}
+			decl.X = x
 		default:
 			log.Fatalf("unsupported stmt: % #v\n", stmts[i])
 		}
@@ -3139,6 +3907,23 @@ func (p *parser) resolveStmts(scope *ast.Scope, stmts []ast.Stmt) []ast.Stmt {
 	return ret
 }
 
+// resolveInterps resolves the interpolations found in x, ie. in the
+// message of @error
+func (p *parser) resolveInterps(scope *ast.Scope, x ast.Expr) {
+	switch v := x.(type) {
+	case *ast.Interp:
+		p.resolveInterp(scope, v)
+	case *ast.StringExpr:
+		for _, x := range v.List {
+			p.resolveInterps(scope, x)
+		}
+	case *ast.ListLit:
+		for _, x := range v.Value {
+			p.resolveInterps(scope, x)
+		}
+	}
+}
+
 func (p *parser) resolveExpr(scope *ast.Scope, expr ast.Expr) (out []*ast.BasicLit) {
 	oldScope := p.topScope
 	p.topScope = scope
@@ -3149,8 +3934,17 @@ func (p *parser) resolveExpr(scope *ast.Scope, expr ast.Expr) (out []*ast.BasicL
 	case *ast.BasicLit:
 		out = append(out, v)
 	case *ast.CallExpr:
-		x, _ := p.resolveCall(v)
-		out = append(out, x.(*ast.BasicLit))
+		x, err := p.resolveCall(v)
+		if err != nil {
+			p.error(v.Pos(), err.Error())
+			return
+		}
+		lit, err := calc.Resolve(x, false, p.env.Precision)
+		if err != nil {
+			p.error(v.Pos(), err.Error())
+			return
+		}
+		out = append(out, lit)
 	case *ast.Interp:
 		p.resolveInterp(scope, v)
 		fmt.Println("resolved...", v.Obj.Decl.(*ast.BasicLit))
@@ -3158,15 +3952,27 @@ func (p *parser) resolveExpr(scope *ast.Scope, expr ast.Expr) (out []*ast.BasicL
 	case *ast.Ident:
 		if v.Obj != nil {
 			log.Println("ident had previous value, this is an error")
-			return basicLitFromIdent(v)
+			return p.basicLitFromIdent(v)
 		}
 		assert(v.Obj == nil, "statement had previous value, was it copied correctly?")
 		p.resolve(v)
-		out = basicLitFromIdent(v)
+		out = p.basicLitFromIdent(v)
 	case *ast.ListLit:
 		for _, x := range v.Value {
 			out = append(out, p.resolveExpr(scope, x)...)
 		}
+	case *ast.UnaryExpr, *ast.BinaryExpr:
+		x, err := p.resolveCall(v)
+		if err != nil {
+			p.error(v.Pos(), err.Error())
+			return
+		}
+		lit, err := calc.Resolve(x, true, p.env.Precision)
+		if err != nil {
+			p.error(v.Pos(), err.Error())
+			return
+		}
+		out = append(out, lit)
 	default:
 		panic(fmt.Errorf("unsupported expr % #v", v))
 	}
@@ -3175,6 +3981,32 @@ func (p *parser) resolveExpr(scope *ast.Scope, expr ast.Expr) (out []*ast.BasicL
 
 // resolveDecl reevalutes all found IDENTs with new scope provided by
 // arg list.
+// resolveAssign evaluates the value of an assignment in the body of a
+// mixin or function ie. $a: $x + 1. Calls resolving to lists and maps
+// are kept so they can be passed to other functions.
+func (p *parser) resolveAssign(x ast.Expr) ast.Expr {
+	switch x.(type) {
+	case *ast.CallExpr, *ast.BinaryExpr, *ast.UnaryExpr:
+	default:
+		return x
+	}
+	res, err := p.resolveCall(x)
+	if err != nil {
+		p.error(x.Pos(), err.Error())
+		return x
+	}
+	switch res.(type) {
+	case *ast.ListLit, *ast.MapLit:
+		return res
+	}
+	lit, err := calc.Resolve(res, true, p.env.Precision)
+	if err != nil {
+		p.error(x.Pos(), err.Error())
+		return x
+	}
+	return lit
+}
+
 func (p *parser) resolveDecl(scope *ast.Scope, decl *ast.DeclStmt) {
 	if p.trace {
 		defer un(trace(p, "ResolveDecl"))
@@ -3208,12 +4040,12 @@ func (p *parser) resolveDecl(scope *ast.Scope, decl *ast.DeclStmt) {
 // TODO: delete this, calc.Resolve can do it
 // basicLitFromIdent recursively resolves an Ident until a
 // basic lit is uncovered.
-func basicLitFromIdent(ident *ast.Ident) (lit []*ast.BasicLit) {
+func (p *parser) basicLitFromIdent(ident *ast.Ident) (lit []*ast.BasicLit) {
 	assert(ident.Obj != nil, "ident has not been resolved")
 	decl := ident.Obj.Decl
 	switch typ := decl.(type) {
 	case *ast.Ident:
-		return basicLitFromIdent(typ)
+		return p.basicLitFromIdent(typ)
 	case *ast.AssignStmt:
 		var lits []*ast.BasicLit
 		//lits := make([]*ast.BasicLit, 0, len(typ.Rhs))
@@ -3222,14 +4054,18 @@ func basicLitFromIdent(ident *ast.Ident) (lit []*ast.BasicLit) {
 			var lit *ast.BasicLit
 			switch rtyp := rhs.(type) {
 			case *ast.Ident:
-				lits = append(lits, basicLitFromIdent(rtyp)...)
+				lits = append(lits, p.basicLitFromIdent(rtyp)...)
 				continue
 			case *ast.BasicLit:
 				lit = rtyp
 			case *ast.ListLit:
 				var err error
-				lit, err = calc.Resolve(rtyp, rtyp.Paren)
+				lit, err = calc.Resolve(rtyp, rtyp.Paren, p.env.Precision)
 				assert(err == nil, "calc resolve failed")
+			case *ast.MapLit:
+				// maps have no CSS value, they are only useful
+				// as function arguments ie. nth($map, 1)
+				continue
 			default:
 				log.Fatalf("illegal Rhs expr % #v\n", rtyp)
 			}
@@ -3264,7 +4100,9 @@ func joinLits(a []*ast.BasicLit, sep string) string {
 func (p *parser) resolveFuncDecl(scope *ast.Scope, call *ast.CallExpr) (ast.Expr, error) {
 	ident := call.Fun.(*ast.Ident)
 
-	p.tryResolve(ident, false)
+	if ident.Obj == nil {
+		p.tryResolve(ident, false)
+	}
 	assert(ident.Obj != nil, "failed to locate function: "+ident.Name)
 	args := call.Args
 	fnDecl := ident.Obj.Decl.(*ast.FuncDecl)
@@ -3293,8 +4131,8 @@ func (p *parser) resolveFuncDecl(scope *ast.Scope, call *ast.CallExpr) (ast.Expr
 	// All the identifiers within this list need to be re-resolved
 	// with the args passed in the include
 	p.openScope()
-	p.processFuncArgs(scope, copyparams, copyargs)
-	stmts = p.resolveStmts(scope, stmts)
+	p.processFuncArgs(p.topScope, copyparams, copyargs)
+	stmts = p.resolveStmts(p.topScope, stmts)
 	p.closeScope()
 	// The last statement should be @return
 	ret, ok := stmts[len(stmts)-1].(*ast.ReturnStmt)
@@ -3335,7 +4173,9 @@ func (p *parser) resolveIncludeSpec(spec *ast.IncludeSpec) {
 	// with the args passed in the include
 	p.openScope()
 	p.processFuncArgs(p.topScope, copyparams, copyargs)
+	p.env.EnterMixin(spec.Body)
 	spec.List = p.resolveStmts(p.topScope, spec.List)
+	p.env.ExitMixin()
 	p.closeScope()
 }
 
@@ -3357,6 +4197,11 @@ func (p *parser) parseIncludeSpec(doResolve bool) *ast.IncludeSpec {
 		Name:   ident,
 		Params: args,
 	}
+	p.includes = append(p.includes, spec)
+	// content block, resolved in the scope of the include
+	if p.tok == token.LBRACE {
+		spec.Body = p.parseBody(p.topScope)
+	}
 
 	if doResolve {
 		p.resolveIncludeSpec(spec)
@@ -3460,11 +4305,18 @@ func (p *parser) parseDecl(sync func(*parser)) ast.Decl {
 		defer un(trace(p, "Declaration"))
 	}
 
+	if cmt := p.checkComment(); cmt != nil {
+		return &ast.CommDecl{CommStmt: cmt}
+	}
+
 	var f parseSpecFunction
 	switch p.tok {
 	case token.SEMICOLON:
 		p.next()
 		return nil
+	case token.COMMENT:
+		group, _ := p.consumeCommentGroup(0)
+		return &ast.CommDecl{CommStmt: &ast.CommStmt{Group: group}}
 	case token.VAR:
 		f = p.inferValueSpec
 	case token.FUNC:
@@ -3474,7 +4326,7 @@ func (p *parser) parseDecl(sync func(*parser)) ast.Decl {
 		return p.parseRuleSelDecl()
 	case token.INCLUDE:
 		return p.parseGenDecl("", token.INCLUDE, p.parseIncludeSpecFn)
-	case token.RULE, token.IDENT:
+	case token.RULE, token.IDENT, token.INTERP:
 		return p.parseRuleDecl()
 	case token.IMPORT:
 		// s := &ast.DeclStmt{Decl: p.parse}
@@ -3484,6 +4336,19 @@ func (p *parser) parseDecl(sync func(*parser)) ast.Decl {
 	case token.IF:
 		stmt := p.parseIfStmt()
 		return &ast.IfDecl{IfStmt: stmt}
+	case token.ELSE, token.ELSEIF:
+		pos := p.pos
+		p.error(pos, "@else must follow @if")
+		sync(p)
+		return &ast.BadDecl{From: pos, To: p.pos}
+	case token.MEDIA:
+		return &ast.MediaDecl{MediaStmt: p.parseMediaStmt()}
+	case token.ATRULE:
+		return &ast.AtRuleDecl{AtRuleStmt: p.parseAtRuleStmt()}
+	case token.ATROOT:
+		return &ast.AtRootDecl{AtRootStmt: p.parseAtRootStmt()}
+	case token.DEBUG, token.WARN, token.ERROR:
+		return &ast.DebugDecl{DebugStmt: p.parseDebugStmt()}
 	default:
 		pos := p.pos
 		p.errorExpected(pos, "declaration")
@@ -3515,6 +4380,14 @@ func (p *parser) parseFile() *ast.File {
 
 	p.openScope()
 	p.pkgScope = p.topScope
+	if p.env != nil {
+		// variables defined before parsing ie. Context.SetVar
+		for name, decl := range p.env.Vars {
+			obj := ast.NewObj(ast.Var, name)
+			obj.Decl = decl
+			p.pkgScope.Objects[name] = obj
+		}
+	}
 	var decls []ast.Decl
 	// Bypass importing for now
 	// if p.mode&PackageClauseOnly == 0 {
@@ -3528,9 +4401,14 @@ func (p *parser) parseFile() *ast.File {
 		for p.tok != token.EOF {
 			decls = append(decls, p.parseDecl(syncDecl))
 		}
+		// comments at the end of the file
+		if cmt := p.checkComment(); cmt != nil {
+			decls = append(decls, &ast.CommDecl{CommStmt: cmt})
+		}
 	}
 
 	// }
+	p.resolveExtends(decls)
 	p.closeScope()
 	assert(p.topScope == nil, "unbalanced scopes")
 	assert(p.labelScope == nil, "unbalanced label scopes")
@@ -3555,7 +4433,9 @@ func (p *parser) parseFile() *ast.File {
 		Decls:      decls,
 		Scope:      p.pkgScope,
 		Imports:    p.imports,
+		Includes:   p.includes,
 		Unresolved: p.unresolved[0:i],
+		Unused:     p.unusedVars(),
 		Comments:   p.comments,
 	}
 }
@@ -0,0 +1,38 @@
+package scanner
+
+// Cache holds the source of files and the tokens scanned from them by
+// absolute path. Tokens do not depend on where a file is imported, so
+// a file imported more than once is read and scanned once.
+type Cache struct {
+	files map[string]*CachedFile
+}
+
+// CachedFile is a file held by a Cache. Items is nil until the file
+// was scanned to the end without errors.
+type CachedFile struct {
+	Src   []byte
+	Items []Item
+}
+
+// NewCache returns an empty Cache
+func NewCache() *Cache {
+	return &Cache{files: make(map[string]*CachedFile)}
+}
+
+// Get returns the file cached for filename
+func (c *Cache) Get(filename string) (*CachedFile, bool) {
+	f, ok := c.files[filename]
+	return f, ok
+}
+
+// Put caches the source of filename, its Items are added once scanned
+func (c *Cache) Put(filename string, src []byte) *CachedFile {
+	f := &CachedFile{Src: src}
+	c.files[filename] = f
+	return f
+}
+
+// Len returns the number of files cached
+func (c *Cache) Len() int {
+	return len(c.files)
+}
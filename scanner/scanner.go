@@ -73,13 +73,52 @@ type prefetch struct {
 	lit string
 }
 
+// prefetchQueue is a FIFO queue of tokens scanned ahead, backed by a
+// ring buffer that grows as needed
+type prefetchQueue struct {
+	buf     []prefetch
+	head, n int
+}
+
+func (q *prefetchQueue) push(pre prefetch) {
+	if q.n == len(q.buf) {
+		q.grow()
+	}
+	q.buf[(q.head+q.n)%len(q.buf)] = pre
+	q.n++
+}
+
+func (q *prefetchQueue) pop() (prefetch, bool) {
+	if q.n == 0 {
+		return prefetch{}, false
+	}
+	pre := q.buf[q.head]
+	q.head = (q.head + 1) % len(q.buf)
+	q.n--
+	return pre, true
+}
+
+// grow doubles the buffer, moving queued tokens to the front
+func (q *prefetchQueue) grow() {
+	size := 2 * len(q.buf)
+	if size == 0 {
+		size = 8
+	}
+	buf := make([]prefetch, size)
+	for i := 0; i < q.n; i++ {
+		buf[i] = q.buf[(q.head+i)%len(q.buf)]
+	}
+	q.buf = buf
+	q.head = 0
+}
+
 type Scanner struct {
 	src    []byte
 	ch     rune
 	offset int
 
-	// hack use a channel as a FIFO queue, this is probably a terrible idea
-	queue chan prefetch
+	// tokens scanned ahead while resolving ambiguous tokens
+	queue prefetchQueue
 
 	mode Mode
 
@@ -97,6 +136,15 @@ type Scanner struct {
 	// inQuote is a hack to apply different text rules whilst
 	// inside quotes
 	inQuote rune
+	// interpQuote holds inQuote while scanning an interpolation
+	// found inside quotes
+	interpQuote rune
+	// inURL is set while scanning the contents of an unquoted
+	// url(), these are not tokenized
+	inURL bool
+	// interpURL holds inURL while scanning an interpolation found
+	// inside of url()
+	interpURL bool
 
 	file       *token.File
 	dir        string
@@ -123,10 +171,7 @@ func (s *Scanner) Init(file *token.File, src []byte, err ErrorHandler, mode Mode
 	s.src = src
 	s.err = err
 	s.mode = mode
-	// There should never more than 2 in the queue, but buffer to 10
-	// just to be safe
-	// Correction, selectors now use the queue and it can grow large
-	s.queue = make(chan prefetch, 25)
+	s.queue = prefetchQueue{}
 	s.rhs = false
 
 	s.ch = ' '
@@ -152,8 +197,9 @@ func (s *Scanner) rewind(offs int) {
 
 // backup one rune
 func (s *Scanner) backup() {
-	w := utf8.RuneLen(s.ch)
-	s.rdOffset -= w
+	// width actually read, utf8.RuneLen reports 3 for the RuneError
+	// returned on a single invalid byte
+	s.rdOffset = s.offset
 
 	// Copy of slice, this is expensive
 	r, w := utf8.DecodeLastRune(s.src[:s.rdOffset])
@@ -161,6 +207,9 @@ func (s *Scanner) backup() {
 	s.ch = r
 }
 
+// next reads the next rune into s.ch. At the end of src s.ch is -1
+// and s.offset is len(src), calling next again leaves the Scanner
+// unchanged.
 func (s *Scanner) next() {
 
 	if s.rdOffset < len(s.src) {
@@ -198,12 +247,6 @@ func (s *Scanner) next() {
 
 }
 
-type Item struct {
-	Type  token.Token
-	Pos   int
-	Value string
-}
-
 func (s *Scanner) skipWhitespace() {
 	for s.ch == ' ' || s.ch == '\t' || s.ch == '\n' || s.ch == '\r' {
 		s.next()
@@ -239,27 +282,76 @@ func (s *Scanner) Scan() (pos token.Pos, tok token.Token, lit string) {
 
 	// Check the queue, which may contain tokens that were fetched
 	// in a previous scan while determing ambiguious tokens.
-	select {
-	case pre := <-s.queue:
+	if pre, ok := s.queue.pop(); ok {
 		pos, tok, lit = pre.pos, pre.tok, pre.lit
-		return
-	default:
-		// If the queue is empty, do nothing
+	} else {
+		// If the queue is empty, scan
+		pos, tok, lit = s.scan()
 	}
 
-	//pos, tok, lit = s.scan(-1)
-	return s.scan()
+	// null, true, false and the boolean operators are keywords
+	// outside of quotes
+	if s.inQuote == 0 {
+		tok, lit = keyword(tok, lit)
+	}
+	return
+}
+
+// keyword converts the value keywords found as tok to their own
+// token. Words before parens are scanned as IDENT ie. not (x), these
+// are keywords too. Like other operators, not, and, or have no literal.
+func keyword(tok token.Token, lit string) (token.Token, string) {
+	if tok != token.STRING && tok != token.IDENT {
+		return tok, lit
+	}
+	switch lit {
+	case "null":
+		tok = token.NULL
+	case "true", "false":
+		tok = token.BOOL
+	case "not":
+		return token.NOT, ""
+	case "and":
+		return token.LAND, ""
+	case "or":
+		return token.LOR, ""
+	}
+	return tok, lit
 }
 
 func (s *Scanner) scan() (pos token.Pos, tok token.Token, lit string) {
 
+	// the rest of an unquoted url() following an interpolation
+	if s.inURL && !s.isInterp() {
+		return s.scanURLText(s.offset)
+	}
+
+	// Text inside quotes is never a symbol, ie. ","
+	if s.inQuote > 0 && s.ch != s.inQuote && s.ch != '#' && s.ch != -1 {
+		if pos, tok, lit = s.scanQuotedText(s.offset); tok != token.ILLEGAL {
+			return
+		}
+	}
+
 scanAgain:
 	s.skipWhitespace()
 	pos = s.file.Pos(s.offset)
 	offs := s.offset
 	ch := s.ch
 
+	if ch == 'u' && s.isURL(offs) {
+		return s.scanURL(offs)
+	}
+
 	switch {
+	case ch == '%':
+		// placeholder selector %name, otherwise modulo
+		s.next()
+		letter := isLetter(s.ch)
+		s.rewind(offs)
+		if letter {
+			pos, tok, lit = s.scanDelim(s.offset)
+		}
 	case ch == '>':
 		offs := s.offset
 		s.next()
@@ -284,14 +376,19 @@ scanAgain:
 		// rule:  IDENT followed by : it must then be followed by ; or }
 		// value: same as above but after the colon followed by ; or }
 		pos, tok, lit = s.scanDelim(s.offset)
+	case ch == '#' && s.isInterpSel(offs):
+		// selector starting with interpolation ie. #{$sel} {
+		pos, tok, lit = s.scanDelim(s.offset)
+	case '0' <= ch && ch <= '9' && s.isKeyframeSel():
+		pos, tok, lit = s.scanKeyframeSel(offs)
 	case '0' <= ch && ch <= '9':
 		// This can not be a selector
 		tok, lit = s.scanNumber(false)
 		utok, ulit := s.scanUnit()
 		if utok != token.ILLEGAL {
 			tok = utok
-			lit = lit + ulit
 		}
+		lit = lit + ulit
 	}
 
 	if tok != token.ILLEGAL {
@@ -316,11 +413,23 @@ bypassSelector:
 			// tok, lit = s.scanInterp(offs)
 			tok, lit = token.INTERP, "#{"
 			s.next()
+			if s.inQuote > 0 {
+				s.interpQuote, s.inQuote = s.inQuote, 0
+			}
+			if s.inURL {
+				s.interpURL, s.inURL = true, false
+			}
 		} else {
 			tok, lit = s.scanColor()
 		}
 	case ':':
-		if isLetter(s.ch) {
+		// a colon touching the name before it ends a rule ie. a{b:c},
+		// otherwise it may start a pseudo selector ie. :hover {
+		var prev rune
+		if offs > 0 {
+			prev = rune(s.src[offs-1])
+		}
+		if isLetter(s.ch) && !(isLetter(prev) || isDigit(prev) || prev == '-') {
 			pos, tok, lit = s.scanDelim(offs)
 		} else {
 			// s.rhs = true
@@ -331,6 +440,13 @@ bypassSelector:
 			pos, tok, lit = s.scanRule(offs)
 		} else {
 			tok = token.SUB
+			// a minus touching only the operand after it is a sign,
+			// the literal tells the parser ie. 1px -2px is a list
+			// while 1px - 2px and 1px-2px subtract
+			if offs > 0 && isSpace(rune(s.src[offs-1])) &&
+				(isDigit(s.ch) || s.ch == '.' || s.ch == '$' || s.ch == '(') {
+				lit = "-"
+			}
 		}
 	case '\'':
 		// toggle inQuote mode, ignore all other runes
@@ -356,8 +472,8 @@ bypassSelector:
 			utok, ulit := s.scanUnit()
 			if utok != token.ILLEGAL {
 				tok = utok
-				lit = lit + ulit
 			}
+			lit = lit + ulit
 		} else {
 			tok = token.PERIOD
 		}
@@ -387,15 +503,7 @@ bypassSelector:
 	case '=':
 		tok = s.switch2(token.ASSIGN, token.EQL)
 	case '!':
-		for isLetter(s.ch) {
-			s.next()
-		}
-		// !global !default
-		if s.offset-offs > 1 {
-			tok = token.STRING
-		} else {
-			tok = s.switch2(token.NOT, token.NEQ)
-		}
+		tok, lit = s.scanBang()
 	case ',':
 		tok = token.COMMA
 	case ';':
@@ -419,6 +527,12 @@ bypassSelector:
 		tok = token.LBRACE
 	case '}':
 		tok = token.RBRACE
+		if s.interpQuote > 0 {
+			s.inQuote, s.interpQuote = s.interpQuote, 0
+		}
+		if s.interpURL {
+			s.inURL, s.interpURL = true, false
+		}
 	case '%':
 		tok = token.REM
 	case '+':
@@ -436,6 +550,34 @@ bypassSelector:
 	return
 }
 
+// scanBang scans the keyword following a '!' ie. !important, !default.
+// Whitespace is allowed between them, so ! important is normalized
+// to !important.
+func (s *Scanner) scanBang() (tok token.Token, lit string) {
+	i := s.offset
+	for i < len(s.src) && (s.src[i] == ' ' || s.src[i] == '\t') {
+		i++
+	}
+	if i > s.offset && i < len(s.src) && isLetter(rune(s.src[i])) {
+		for s.ch == ' ' || s.ch == '\t' {
+			s.next()
+		}
+	}
+	start := s.offset
+	for isLetter(s.ch) {
+		s.next()
+	}
+	word := string(s.src[start:s.offset])
+	switch {
+	case strings.ToLower(word) == "important":
+		return token.IMPORTANT, "!important"
+	case len(word) > 0:
+		// !global !default
+		return token.STRING, "!" + word
+	}
+	return s.switch2(token.NOT, token.NEQ), ""
+}
+
 // this won't be around for long
 func isValue(ch rune, whitespace bool) bool {
 	if ch == '-' || ch == '!' {
@@ -475,11 +617,14 @@ var colondelim = []byte(":")
 // a#id { // 'a#id'
 // { color: blue; } // 'color' ':' 'blue'
 func (s *Scanner) scanDelim(offs int) (pos token.Pos, tok token.Token, lit string) {
-	printf("Delim: %q\n", string(s.src[s.offset:]))
-	defer func() {
-		printf("finDelim %s:%q\n", tok, lit)
-		printf("rest? %q\n", string(s.src[s.offset:]))
-	}()
+	// the remaining source is only copied while tracing
+	if trace {
+		printf("Delim: %q\n", string(s.src[s.offset:]))
+		defer func() {
+			printf("finDelim %s:%q\n", tok, lit)
+			printf("rest? %q\n", string(s.src[s.offset:]))
+		}()
+	}
 
 	pos = s.file.Pos(offs)
 	var ch rune
@@ -515,6 +660,21 @@ L:
 		goto L
 	}
 
+	// Pseudo selectors ie. a:hover { contain a colon, skip ahead
+	// to the selector start
+	if s.ch == ':' && s.inQuote == 0 && s.isPseudoSel() {
+		for s.ch != '{' {
+			s.next()
+		}
+	}
+	// Functional pseudo-classes starting a selector ie. :not(.a) {
+	if s.ch == '(' && s.inQuote == 0 && s.src[offs] == ':' &&
+		s.isBlockAhead(s.src[s.offset:]) {
+		for s.ch != '{' {
+			s.next()
+		}
+	}
+
 	end := s.offset
 	sel := bytes.TrimSpace(s.src[offs:s.offset])
 	printf("prescanned: %q\n", string(sel))
@@ -561,6 +721,11 @@ L:
 		s.rewind(offs)
 		tok = token.STRING
 		lit = s.scanText(offs, 0, false, isValue)
+		if strings.HasSuffix(lit, "#{") {
+			// interpolation in property name ie. margin-#{$side}
+			s.push(s.file.Pos(s.offset-2), token.INTERP, "#{")
+			return pos, token.RULE, strings.TrimSuffix(lit, "#{")
+		}
 		s.skipWhitespace()
 		if s.ch == ':' {
 			tok = token.RULE
@@ -591,7 +756,7 @@ L:
 		}
 		printf("selector\n")
 		fn = s.selLoop
-		queue = []prefetch{{pos, token.SELECTOR, string(sel)}}
+		queue = []prefetch{{pos, token.SELECTOR, string(stripCR(sel))}}
 	case '\'', '"':
 		// TODO: libSass and Sass preserve some whitespace in quotes
 		panic("should never be selected")
@@ -610,6 +775,11 @@ Q:
 	for {
 		s.skipWhitespace()
 		pos, tok, lit := fn(s.offset)
+		if tok == token.EOF {
+			// typed scanners return EOF indefinitely at the end of src
+			queue = append(queue, prefetch{pos, tok, lit})
+			break
+		}
 		if tok != token.ILLEGAL {
 			queue = append(queue, prefetch{pos, tok, lit})
 			continue
@@ -678,6 +848,107 @@ func (s *Scanner) scanQuoted(offs int) (pos token.Pos, tok token.Token, lit stri
 	return
 }
 
+// scanQuotedText scans to the end of the quote or the start of an
+// interpolation. Unlike scanQuoted, whitespace only text is preserved.
+func (s *Scanner) scanQuotedText(offs int) (pos token.Pos, tok token.Token, lit string) {
+	var ch rune
+	for s.ch != -1 && s.ch != s.inQuote {
+		ch = s.ch
+		s.next()
+		if ch == '\\' && s.ch != -1 {
+			// escaped rune never ends the string
+			s.next()
+			continue
+		}
+		if ch == '#' && s.ch == '{' {
+			s.backup()
+			break
+		}
+	}
+	raw := s.src[offs:s.offset]
+	lit = string(bytes.TrimSpace(raw))
+	if len(lit) == 0 {
+		lit = string(raw)
+	} else {
+		// position starts at the first non-whitespace rune
+		offs += bytes.Index(raw, []byte(lit))
+	}
+	pos = s.file.Pos(offs)
+	if len(lit) > 0 {
+		tok = token.STRING
+	}
+	return
+}
+
+// isInterp reports whether an interpolation starts at the current
+// position
+func (s *Scanner) isInterp() bool {
+	return s.ch == '#' && s.rdOffset < len(s.src) && s.src[s.rdOffset] == '{'
+}
+
+// isURL reports whether an unquoted url() starts at offs. Like Sass,
+// url() holding quotes, variables or calls ie. url($path) is a call
+// to url().
+func (s *Scanner) isURL(offs int) bool {
+	if !bytes.HasPrefix(s.src[offs:], []byte("url(")) {
+		return false
+	}
+	src := s.src[offs+len("url("):]
+	var interp int
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '#' && i+1 < len(src) && src[i+1] == '{':
+			interp++
+			i++
+		case interp > 0:
+			if c == '}' {
+				interp--
+			}
+		case c == ')':
+			return true
+		case c == '\\':
+			i++
+		case c == '$', c == '(', c == '"', c == '\'', c == '\n':
+			return false
+		}
+	}
+	return false
+}
+
+// scanURL scans an unquoted url() as a single STRING ie.
+// url(a.png?v=2#b). Interpolation inside of the url is scanned as
+// usual, the text around it is returned as STRINGs.
+func (s *Scanner) scanURL(offs int) (pos token.Pos, tok token.Token, lit string) {
+	for range "url(" {
+		s.next()
+	}
+	s.inURL = true
+	return s.scanURLText(offs)
+}
+
+// scanURLText scans the contents of url() up to and including the
+// closing ) or up to an interpolation
+func (s *Scanner) scanURLText(offs int) (pos token.Pos, tok token.Token, lit string) {
+	pos, tok = s.file.Pos(offs), token.STRING
+	for s.ch != ')' {
+		switch {
+		case s.ch == -1, s.ch == '\n':
+			s.error(offs, "expected )")
+			s.inURL = false
+			return pos, tok, string(s.src[offs:s.offset])
+		case s.isInterp():
+			return pos, tok, string(s.src[offs:s.offset])
+		case s.ch == '\\':
+			// escaped rune never ends the url
+			s.next()
+		}
+		s.next()
+	}
+	s.next()
+	s.inURL = false
+	return pos, tok, string(s.src[offs:s.offset])
+}
+
 func (s *Scanner) scanHTTP(offs int) (pos token.Pos, tok token.Token, lit string) {
 	var ch rune
 	for isText(s.ch, false) || strings.ContainsRune("-_+=.:/|?,", s.ch) {
@@ -702,17 +973,122 @@ func (s *Scanner) scanHTTP(offs int) (pos token.Pos, tok token.Token, lit string
 	return
 }
 
+// isPseudoSel peeks past the colon at the current position to find
+// whether it begins a pseudo selector ie. a:hover or a property
+// ie. color:red. Pseudo selectors have no whitespace after the colon
+// and are terminated by a {
+func (s *Scanner) isPseudoSel() bool {
+	src := s.src[s.offset+1:]
+	if len(src) == 0 || !(isLetter(rune(src[0])) || src[0] == ':') {
+		return false
+	}
+	return s.isBlockAhead(src)
+}
+
+// isBlockAhead reports whether src opens a block before the end of
+// a declaration
+func (s *Scanner) isBlockAhead(src []byte) bool {
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			return i == 0 || src[i-1] != '#'
+		case ';', '}':
+			return false
+		}
+	}
+	return false
+}
+
+// isInterpSel reports whether the statement starting at offs with an
+// interpolation is a selector, ie. #{$sel} { and not #{$prop}: 1;
+func (s *Scanner) isInterpSel(offs int) bool {
+	src := s.src
+	if s.inQuote > 0 || s.inURL || offs+1 >= len(src) || src[offs+1] != '{' {
+		return false
+	}
+	// interpolation must start the statement
+	for i := offs - 1; i >= 0; i-- {
+		if src[i] == ' ' || src[i] == '\t' {
+			continue
+		}
+		if !strings.ContainsRune("{};\n\r", rune(src[i])) {
+			return false
+		}
+		break
+	}
+	depth := 0
+	for i := offs; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			if i > 0 && src[i-1] == '#' {
+				depth++
+				continue
+			}
+			return depth == 0
+		case '}':
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case ';':
+			return false
+		}
+	}
+	return false
+}
+
+// isKeyframeSel peeks for percentage keyframe selectors
+// ie. 0%, 50% {
+func (s *Scanner) isKeyframeSel() bool {
+	src := s.src[s.offset:]
+	var pct bool
+	for i := 0; i < len(src); i++ {
+		switch ch := src[i]; {
+		case ch == '{':
+			return pct
+		case ch == '%':
+			pct = true
+		case ch == ',', ch == '.', '0' <= ch && ch <= '9', isSpace(rune(ch)):
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// scanKeyframeSel scans percentage keyframe selectors returning
+// a SELECTOR and queueing a STRING for each percentage
+func (s *Scanner) scanKeyframeSel(offs int) (pos token.Pos, tok token.Token, lit string) {
+	pos = s.file.Pos(offs)
+	for s.ch != '{' {
+		s.skipWhitespace()
+		start := s.offset
+		for s.ch != ',' && s.ch != '{' && !isSpace(s.ch) {
+			s.next()
+		}
+		s.push(s.file.Pos(start), token.STRING, string(s.src[start:s.offset]))
+		s.skipWhitespace()
+		if s.ch == ',' {
+			s.push(s.file.Pos(s.offset), token.COMMA, "")
+			s.next()
+		}
+	}
+	return pos, token.SELECTOR, string(bytes.TrimSpace(s.src[offs:s.offset]))
+}
+
 func (s *Scanner) selLoop(offs int) (pos token.Pos, tok token.Token, lit string) {
-	defer func() {
-		printf("selLoop ret %s:%q\n", tok, lit)
-		printf("selLoop res %q\n", string(s.src[s.offset:]))
-	}()
+	if trace {
+		defer func() {
+			printf("selLoop ret %s:%q\n", tok, lit)
+			printf("selLoop res %q\n", string(s.src[s.offset:]))
+		}()
+	}
 	pos = s.file.Pos(offs)
 
 	switch ch := s.ch; {
 	case ch == '{':
 		tok = token.ILLEGAL
-	case ch == '#' || ch == '.':
+	case ch == '#' || ch == '.' || ch == '%':
 		s.next()
 		if !isLetter(s.ch) {
 			if s.ch != '{' {
@@ -727,13 +1103,23 @@ func (s *Scanner) selLoop(offs int) (pos token.Pos, tok token.Token, lit string)
 		}
 		fallthrough
 	// Standard selectors ie. #id .cla div
-	case isLetter(ch):
+	// hyphen continues a selector after interpolation ie. #{$a}-b
+	case isLetter(ch), ch == '-':
 		s.next()
 		s.skipWhitespace()
 		tok = token.STRING
+		var pseudo bool
 		for isLetter(s.ch) || isDigit(s.ch) ||
-			s.ch == '.' || s.ch == '#' {
+			s.ch == '.' || s.ch == '#' || s.ch == '-' || s.ch == ':' ||
+			s.ch == '(' && pseudo {
 			ch = s.ch
+			if ch == '(' {
+				s.scanPseudoArgs()
+				pseudo = false
+				s.skipWhitespace()
+				continue
+			}
+			pseudo = ch == ':' || pseudo && ch != '.' && ch != '#'
 			s.next()
 			if ch == '#' && s.ch == '{' {
 				s.backup()
@@ -761,8 +1147,14 @@ func (s *Scanner) selLoop(offs int) (pos token.Pos, tok token.Token, lit string)
 			tok = token.TIL
 		case '&':
 			tok = token.AND
-			for IsSymbol(s.ch) || isLetter(s.ch) || isDigit(s.ch) ||
-				s.ch == '.' || s.ch == '#' {
+			// suffixes ie. &:hover &.active &__el &-el &:not(.a)
+			for isLetter(s.ch) || isDigit(s.ch) ||
+				s.ch == '.' || s.ch == '#' || s.ch == ':' || s.ch == '-' ||
+				s.ch == '(' {
+				if s.ch == '(' {
+					s.scanPseudoArgs()
+					continue
+				}
 				s.next()
 			}
 			lit = string(bytes.TrimSpace(s.src[offs:s.offset]))
@@ -774,25 +1166,34 @@ func (s *Scanner) selLoop(offs int) (pos token.Pos, tok token.Token, lit string)
 			tok = token.COMMA
 		case '[':
 			tok = token.ATTRIBUTE
-			runes := []rune{ch, s.ch}
-			for s.ch != ']' {
+			var quote rune
+			for quote != 0 || s.ch != ']' {
 				if s.ch == -1 {
 					s.error(offs, "attribute selector not found")
+					break
 				}
-				// TODO check we ever find ']'
-				s.next()
-				if !unicode.IsSpace(s.ch) {
-					runes = append(runes, s.ch)
+				switch {
+				case s.ch == quote:
+					quote = 0
+				case quote == 0 && (s.ch == '"' || s.ch == '\''):
+					quote = s.ch
 				}
+				s.next()
 			}
 			s.next()
-			//lit = string(s.src[offs:s.offset])
-			lit = string(runes)
+			// spacing is normalized with the rest of the selector
+			lit = string(s.src[offs:s.offset])
 		case ':':
 			tok = token.PSEUDO
-			for s.ch != ',' && !unicode.IsSpace(s.ch) {
+			for s.ch != ',' && s.ch != '{' && s.ch != -1 &&
+				!unicode.IsSpace(s.ch) {
+				if s.ch == '(' {
+					s.scanPseudoArgs()
+					continue
+				}
 				s.next()
 			}
+			lit = string(s.src[offs:s.offset])
 		case '/':
 			s.backup()
 			// found a comment, unwind
@@ -805,6 +1206,34 @@ func (s *Scanner) selLoop(offs int) (pos token.Pos, tok token.Token, lit string)
 	return
 }
 
+// scanPseudoArgs consumes the arguments of a functional pseudo-class
+// ie. :not(.a, .b) or :nth-child(2n+1). The arguments are part of the
+// selector, they are not a function call.
+func (s *Scanner) scanPseudoArgs() {
+	offs := s.offset
+	var depth int
+	var quote rune
+	for s.ch != -1 {
+		switch {
+		case quote != 0:
+			if s.ch == quote {
+				quote = 0
+			}
+		case s.ch == '"' || s.ch == '\'':
+			quote = s.ch
+		case s.ch == '(':
+			depth++
+		case s.ch == ')':
+			depth--
+		}
+		s.next()
+		if depth == 0 {
+			return
+		}
+	}
+	s.error(offs, "expected )")
+}
+
 // scanInterpBlock looks forward and matches all recursive interpolations
 // it does not provide any useful lit or tokens and is only used
 // for prescanning text.
@@ -839,9 +1268,17 @@ func (s *Scanner) scanEach(offs int) {
 	// queue the iterator, look for in and parse the list/map
 	s.next()
 	s.push(s.scan())
+	s.skipWhitespace()
+	// multiple iterators ie. @each $key, $value in $map
+	for s.ch == ',' {
+		s.push(s.file.Pos(s.offset), token.COMMA, "")
+		s.next()
+		s.skipWhitespace()
+		s.push(s.scan())
+		s.skipWhitespace()
+	}
 
 	// find 'in'
-	s.skipWhitespace()
 	inoffs := s.offset
 	for isText(s.ch, false) {
 		s.next()
@@ -876,12 +1313,11 @@ func (s *Scanner) scanInterp(offs int) (pos token.Pos, tok token.Token, lit stri
 }
 
 func (s *Scanner) push(pos token.Pos, tok token.Token, lit string) {
-	s.queue <- prefetch{pos, tok, lit}
+	s.queue.push(prefetch{pos, tok, lit})
 }
 
 func (s *Scanner) pushPre(pre prefetch) {
-	// TODO: check for full queue
-	s.queue <- pre
+	s.queue.push(pre)
 }
 
 // scanInterp attempts to build a valid set of tokens from an interpolation
@@ -889,11 +1325,11 @@ func (s *Scanner) queueInterp(offs int) bool {
 	pos, tok, lit := s.scanInterp(offs)
 	if tok == token.INTERP {
 		// If found, just push into the queue for next Scan
-		s.queue <- prefetch{
+		s.pushPre(prefetch{
 			pos: pos,
 			tok: tok,
 			lit: lit,
-		}
+		})
 		return true
 	}
 
@@ -976,6 +1412,28 @@ func (s *Scanner) scanColor() (tok token.Token, lit string) {
 }
 
 // ScanDirective matches Sass directives http://sass-lang.com/documentation/file.SASS_REFERENCE.html#directives
+// scanMixinName queues the name of a mixin without arguments that
+// is followed by a block ie. @include foo { so it is not scanned as
+// a selector
+func (s *Scanner) scanMixinName() {
+	s.skipWhitespace()
+	offs := s.offset
+	for isLetter(s.ch) || isDigit(s.ch) || s.ch == '-' {
+		s.next()
+	}
+	end := s.offset
+	s.skipWhitespace()
+	if end == offs || s.ch != '{' {
+		s.rewind(offs)
+		return
+	}
+	s.pushPre(prefetch{
+		pos: s.file.Pos(offs),
+		tok: token.IDENT,
+		lit: string(s.src[offs:end]),
+	})
+}
+
 func (s *Scanner) scanDirective() (tok token.Token, lit string) {
 	offs := s.offset - 1
 	for isLetter(s.ch) || s.ch == '-' {
@@ -994,13 +1452,19 @@ func (s *Scanner) scanDirective() (tok token.Token, lit string) {
 		s.next()
 		if s.ch == 'f' {
 			s.next()
-			lit = string(s.src[offs:s.offset])
+			// whitespace between else and if is not significant
+			lit = "@else if"
 			tok = token.ELSEIF
 			s.inDirective = true
 			break
 		}
 		// else if check failed
 		s.backup()
+	case "@elseif":
+		// deprecated alias of @else if
+		lit = "@else if"
+		tok = token.ELSEIF
+		s.inDirective = true
 	case "@for":
 		tok = token.FOR
 	case "@each":
@@ -1008,10 +1472,12 @@ func (s *Scanner) scanDirective() (tok token.Token, lit string) {
 		s.scanEach(s.offset)
 	case "@include":
 		tok = token.INCLUDE
+		s.scanMixinName()
 	case "@function":
 		tok = token.FUNC
 	case "@mixin":
 		tok = token.MIXIN
+		s.scanMixinName()
 	case "@return":
 		tok = token.RETURN
 	case "@import":
@@ -1025,13 +1491,24 @@ func (s *Scanner) scanDirective() (tok token.Token, lit string) {
 			s.next()
 		}
 		lit := s.src[offs:s.offset]
-		s.queue <- prefetch{
+		s.pushPre(prefetch{
 			pos: s.file.Pos(offs),
 			tok: token.STRING,
 			lit: string(bytes.TrimSpace(lit)),
-		}
+		})
 	case "@extend":
 		tok = token.EXTEND
+		s.skipWhitespace()
+		// target selector is everything until ; or !optional
+		offs := s.offset
+		for !strings.ContainsRune(";}!", s.ch) && s.ch != -1 {
+			s.next()
+		}
+		s.pushPre(prefetch{
+			pos: s.file.Pos(offs),
+			tok: token.STRING,
+			lit: string(bytes.TrimSpace(s.src[offs:s.offset])),
+		})
 	case "@at-root":
 		tok = token.ATROOT
 	case "@debug":
@@ -1040,6 +1517,23 @@ func (s *Scanner) scanDirective() (tok token.Token, lit string) {
 		tok = token.WARN
 	case "@error":
 		tok = token.ERROR
+	default:
+		// generic at-rules ie. @supports, @keyframes, @font-face
+		// pass their prelude through untouched
+		tok = token.ATRULE
+		s.skipWhitespace()
+		offs := s.offset
+		for !strings.ContainsRune("{;}", s.ch) && s.ch != -1 {
+			s.next()
+		}
+		prelude := bytes.TrimSpace(s.src[offs:s.offset])
+		if len(prelude) > 0 {
+			s.pushPre(prefetch{
+				pos: s.file.Pos(offs),
+				tok: token.STRING,
+				lit: string(prelude),
+			})
+		}
 	}
 
 	return
@@ -1089,11 +1583,11 @@ ruleAgain:
 				// It's like groundhog day, but it's interpolation every day
 				goto ruleAgain
 			}
-			s.queue <- prefetch{
+			s.pushPre(prefetch{
 				pos: pos,
 				lit: "}",
 				tok: token.RBRACE,
-			}
+			})
 			return
 		}
 		// Not sure, this requires more specifics
@@ -1107,13 +1601,42 @@ ruleAgain:
 // scanValue inspects rhs of ':' for every rule blocks
 func (s *Scanner) scanValue(offs int) (pos token.Pos, tok token.Token, lit string) {
 	pos = s.file.Pos(offs)
+	if s.ch == '!' {
+		s.next()
+		tok, lit = s.scanBang()
+		// != is an operator ie. a != b
+		if tok != token.IMPORTANT && tok != token.NEQ {
+			tok = token.STRING
+			lit = string(s.src[offs:s.offset])
+		}
+		return
+	}
 	// Only look for text here, numbers and symbols will be
 	// caught by Scan()
-	if isDigit(s.ch) {
-		tok = token.INT
-	}
 	var maybeFloat bool
+	// numbers may start with a decimal point ie. .5em
+	fraction := s.ch == '.' && s.rdOffset < len(s.src) &&
+		isDigit(rune(s.src[s.rdOffset]))
+	if isDigit(s.ch) || fraction {
+		if fraction {
+			s.next()
+		}
+		tok, lit = s.scanNumber(fraction)
+		maybeFloat = tok == token.FLOAT
+		// numbers with units ie. 12px in font: bold 12px/1.4
+		uoffs := s.offset
+		if utok, _ := s.scanUnit(); utok != token.ILLEGAL {
+			if !isValue(s.ch, false) && !isDigit(s.ch) {
+				return pos, utok, string(s.src[offs:s.offset])
+			}
+		}
+		s.rewind(uoffs)
+	}
 	for s.ch == '$' || isValue(s.ch, false) || isDigit(s.ch) {
+		// flags end the value ie. red!important
+		if s.ch == '!' && s.offset > offs {
+			break
+		}
 		if maybeFloat && isDigit(s.ch) {
 			tok = token.FLOAT
 		} else if maybeFloat {
@@ -1140,7 +1663,8 @@ func (s *Scanner) scanValue(offs int) (pos token.Pos, tok token.Token, lit strin
 
 func (s *Scanner) scanIdent(offs int) (pos token.Pos, tok token.Token, lit string) {
 	pos = s.file.Pos(offs)
-	for isLetter(s.ch) || isDigit(s.ch) || s.ch == '-' {
+	for isLetter(s.ch) || isDigit(s.ch) || s.ch == '-' || s.ch == '.' {
+		// module functions ie. string.split
 		s.next()
 	}
 	lit = string(s.src[offs:s.offset])
@@ -1189,10 +1713,9 @@ func (s *Scanner) scanUnit() (token.Token, string) {
 		tok = token.UREM
 	case "%":
 		tok = token.UPCT
-	default:
-		lit = ""
 	}
 
+	// unknown units ie. 1fr are returned with token.ILLEGAL
 	return tok, lit
 }
 
@@ -6,9 +6,11 @@
 package scanner
 
 import (
+	"fmt"
 	"log"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/wellington/sass/token"
 )
@@ -133,6 +135,18 @@ func TestScan_directive(t *testing.T) {
 		{token.ELSEIF, "@else if"},
 	})
 
+	testScan(t, []elt{
+		{token.ATRULE, "@charset"},
+		{token.STRING, `"UTF-8"`},
+		{token.SEMICOLON, ";"},
+	})
+
+	testScan(t, []elt{
+		{token.ATRULE, "@page"},
+		{token.STRING, ":first"},
+		{token.LBRACE, "{"},
+	})
+
 	testScan(t, []elt{
 		{token.MEDIA, "@media"},
 		{token.STRING, "print and (foo: 1 2 3), (bar: 3px hux(muz)), not screen"},
@@ -233,6 +247,29 @@ func TestScan_quotes(t *testing.T) {
 	})
 }
 
+func TestScan_quote_escapes(t *testing.T) {
+	oldWs := whitespace
+	defer func() {
+		whitespace = oldWs
+	}()
+	whitespace = ""
+	testScanMap(t, `'it\'s'`, []elt{
+		{token.QSSTRING, "'"},
+		{token.STRING, `it\'s`},
+		{token.QSSTRING, "'"},
+	})
+	testScanMap(t, `"a\\"`, []elt{
+		{token.QSTRING, `"`},
+		{token.STRING, `a\\`},
+		{token.QSTRING, `"`},
+	})
+	testScanMap(t, `"\2014"`, []elt{
+		{token.QSTRING, `"`},
+		{token.STRING, `\2014`},
+		{token.QSTRING, `"`},
+	})
+}
+
 func TestScan_selectors(t *testing.T) {
 	testScan(t, []elt{
 		// {token.SELECTOR, "i#grer"}
@@ -306,6 +343,70 @@ func TestScan_selectors(t *testing.T) {
 	})
 }
 
+func TestScan_selector_suffix(t *testing.T) {
+	testScan(t, []elt{
+		{token.AND, "&:hover"},
+		{token.COMMA, ","},
+		{token.AND, "&__el"},
+		{token.COMMA, ","},
+		{token.STRING, "a:hover"},
+		{token.LBRACE, "{"},
+		{token.RULE, "color"},
+		{token.COLON, ":"},
+		{token.COLOR, "#fff"},
+		{token.SEMICOLON, ";"},
+		{token.RBRACE, "}"},
+	})
+}
+
+func TestScan_else_if_chain(t *testing.T) {
+	testScan(t, []elt{
+		{token.IF, "@if"},
+		{token.VAR, "$x"},
+		{token.EQL, "=="},
+		{token.INT, "1"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.ELSEIF, "@else if"},
+		{token.VAR, "$x"},
+		{token.EQL, "=="},
+		{token.INT, "2"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.ELSE, "@else"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+	})
+
+	s := &Scanner{}
+	src := []byte("@else   if $x {} @elseif $y {}")
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), src, nil, 0)
+	for _, e := range []token.Token{token.ELSEIF, token.VAR, token.LBRACE,
+		token.RBRACE, token.ELSEIF} {
+		_, tok, lit := s.Scan()
+		if tok != e {
+			t.Fatalf("got: %s wanted: %s", tok, e)
+		}
+		if tok == token.ELSEIF && lit != "@else if" {
+			t.Errorf("got: %q wanted: %q", lit, "@else if")
+		}
+	}
+}
+
+func TestScan_keyframes(t *testing.T) {
+	testScan(t, []elt{
+		{token.ATRULE, "@keyframes"},
+		{token.STRING, "spin"},
+		{token.LBRACE, "{"},
+		{token.STRING, "0%"},
+		{token.COMMA, ","},
+		{token.STRING, "100%"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.RBRACE, "}"},
+	})
+}
+
 func TestScan_nested(t *testing.T) {
 	testScan(t, []elt{
 		{token.AND, "&"},
@@ -418,6 +519,89 @@ func TestScan_value(t *testing.T) {
 
 }
 
+func TestScan_null(t *testing.T) {
+	testScan(t, []elt{
+		{token.VAR, "$x"},
+		{token.NULL, "null"},
+		{token.STRING, "a"},
+		{token.NULL, "null"},
+		{token.SEMICOLON, ";"},
+	})
+
+	oldWs := whitespace
+	defer func() {
+		whitespace = oldWs
+	}()
+	whitespace = ""
+	testScanMap(t, `"null"`, []elt{
+		{token.QSTRING, `"`},
+		{token.STRING, "null"},
+		{token.QSTRING, `"`},
+	})
+}
+
+func TestScan_bool(t *testing.T) {
+	testScan(t, []elt{
+		{token.VAR, "$x"},
+		{token.BOOL, "true"},
+		{token.BOOL, "false"},
+		{token.SEMICOLON, ";"},
+	})
+
+	testScan(t, []elt{
+		{token.IF, "@if"},
+		{token.BOOL, "true"},
+		{token.LBRACE, "{"},
+	})
+
+	testScan(t, []elt{
+		{token.VAR, "$x"},
+		{token.NOT, "not"},
+		{token.BOOL, "true"},
+		{token.LOR, "or"},
+		{token.VAR, "$y"},
+		{token.LAND, "and"},
+		{token.NOT, "not"},
+		{token.LPAREN, "("},
+		{token.BOOL, "false"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+	})
+
+	oldWs := whitespace
+	defer func() {
+		whitespace = oldWs
+	}()
+	whitespace = ""
+	testScanMap(t, `'true'`, []elt{
+		{token.QSSTRING, "'"},
+		{token.STRING, "true"},
+		{token.QSSTRING, "'"},
+	})
+}
+
+func TestScan_pseudo_args(t *testing.T) {
+	testScan(t, []elt{
+		{token.STRING, "a:not(.a, .b)"},
+		{token.LBRACE, "{"},
+	})
+
+	testScan(t, []elt{
+		{token.STRING, "li:nth-child(2n+1)"},
+		{token.LBRACE, "{"},
+	})
+
+	testScan(t, []elt{
+		{token.PSEUDO, ":not(:first-child)"},
+		{token.LBRACE, "{"},
+	})
+
+	testScan(t, []elt{
+		{token.AND, "&:not(.x)"},
+		{token.LBRACE, "{"},
+	})
+}
+
 func TestScan_attr_sel_now(t *testing.T) {
 	testScan(t, []elt{
 		//{token.SELECTOR},
@@ -450,6 +634,14 @@ func TestScan_math(t *testing.T) {
 		{token.COLOR, "#eee"},
 		{token.SEMICOLON, ";"},
 	})
+
+	testScan(t, []elt{
+		{token.VAR, "$x"},
+		{token.STRING, "a"},
+		{token.NEQ, "!="},
+		{token.STRING, "b"},
+		{token.SEMICOLON, ";"},
+	})
 }
 
 func TestScan_if(t *testing.T) {
@@ -610,11 +802,7 @@ func testScanMap(t *testing.T, v interface{}, tokens []elt) {
 			if elit[1] == '/' {
 				elit = elit[0 : len(elit)-1]
 			}
-		case token.ATTRIBUTE:
-			// FIXME: the parser should eliminate whitespace
-			// from selector attributes
-			elit = strings.Replace(e.lit, " ", "", -1)
-		case token.IDENT:
+		case token.ATTRIBUTE, token.IDENT:
 			elit = e.lit
 		case token.SEMICOLON:
 			elit = ";"
@@ -683,7 +871,7 @@ func TestScan_decl(t *testing.T) {
 		{token.LBRACE, "{"},
 		{token.VAR, "$x"},
 		{token.COLON, ":"},
-		{token.STRING, "false"},
+		{token.BOOL, "false"},
 		{token.STRING, "!global"},
 		{token.SEMICOLON, ";"},
 		{token.RETURN, "@return"},
@@ -699,3 +887,350 @@ func TestScan_decl(t *testing.T) {
 	// }
 
 }
+
+func TestScan_important(t *testing.T) {
+	testScan(t, []elt{
+		{token.VAR, "$x"},
+		{token.COLON, ":"},
+		{token.STRING, "red"},
+		{token.IMPORTANT, "!important"},
+		{token.SEMICOLON, ";"},
+	})
+}
+
+func TestScan_crlf(t *testing.T) {
+	lf := `// silent
+/* loud
+   comment */
+@import "a";
+$x: 1px;
+.a,
+.b > p {
+  @extend .c;
+  e: 1px
+    $x;
+  f: "q #{$x}";
+  g: #{$x}-i;
+}
+`
+	crlf := strings.Replace(lf, "\n", "\r\n", -1)
+
+	type tpos struct {
+		tok  token.Token
+		lit  string
+		line int
+	}
+	scan := func(src string) []tpos {
+		var s Scanner
+		file := fset.AddFile("", fset.Base(), len(src))
+		s.Init(file, []byte(src), func(pos token.Position, msg string) {
+			t.Errorf("%s: %s", pos, msg)
+		}, ScanComments)
+		var toks []tpos
+		for {
+			pos, tok, lit := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+			if strings.Contains(lit, "\r") {
+				t.Errorf("%s contains carriage return: %q", tok, lit)
+			}
+			toks = append(toks, tpos{tok, lit, file.Line(pos)})
+		}
+		return toks
+	}
+
+	e, toks := scan(lf), scan(crlf)
+	if len(e) != len(toks) {
+		t.Fatalf("got: %d tokens wanted: %d", len(toks), len(e))
+	}
+	for i := range e {
+		if e[i] != toks[i] {
+			t.Errorf("got: %v wanted: %v", toks[i], e[i])
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	items, err := Tokens("div { color: red; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := []Item{
+		{token.SELECTOR, 0, "div"},
+		{token.STRING, 0, "div"},
+		{token.LBRACE, 4, ""},
+		{token.RULE, 6, "color"},
+		{token.COLON, 11, ""},
+		{token.STRING, 13, "red"},
+		{token.SEMICOLON, 16, ";"},
+		{token.RBRACE, 18, ""},
+		{token.EOF, 19, ""},
+	}
+	if len(items) != len(e) {
+		t.Fatalf("got: %v wanted: %v", items, e)
+	}
+	for i := range e {
+		if items[i] != e[i] {
+			t.Errorf("%d got: %v wanted: %v", i, items[i], e[i])
+		}
+	}
+}
+
+func TestTokens_important_unspaced(t *testing.T) {
+	// compressed output ie. a{b:1px!important;c:red!important}
+	items, err := Tokens("a{b:1px!important;c:red!important}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := []Item{
+		{token.SELECTOR, 0, "a"},
+		{token.STRING, 0, "a"},
+		{token.LBRACE, 1, ""},
+		{token.RULE, 2, "b"},
+		{token.COLON, 3, ""},
+		{token.UPX, 4, "1px"},
+		{token.IMPORTANT, 7, "!important"},
+		{token.SEMICOLON, 17, ";"},
+		{token.RULE, 18, "c"},
+		{token.COLON, 19, ""},
+		{token.STRING, 20, "red"},
+		{token.IMPORTANT, 23, "!important"},
+		{token.RBRACE, 33, ""},
+		{token.EOF, 34, ""},
+	}
+	if len(items) != len(e) {
+		t.Fatalf("got: %v wanted: %v", items, e)
+	}
+	for i := range e {
+		if items[i] != e[i] {
+			t.Errorf("%d got: %v wanted: %v", i, items[i], e[i])
+		}
+	}
+}
+
+func TestTokens_value_unit(t *testing.T) {
+	items, err := Tokens("a { font: bold 12px/1.4 serif; b: 1fr; }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := map[string]token.Token{
+		"12px": token.UPX,
+		"1.4":  token.FLOAT,
+		"1fr":  token.INT,
+	}
+	for _, item := range items {
+		if tok, ok := e[item.Value]; ok {
+			if item.Type != tok {
+				t.Errorf("%s got: %s wanted: %s", item.Value, item.Type, tok)
+			}
+			delete(e, item.Value)
+		}
+	}
+	for lit := range e {
+		t.Errorf("%s not found", lit)
+	}
+}
+
+func TestTokens_sign(t *testing.T) {
+	tests := []struct {
+		in  string
+		lit string
+	}{
+		{"a { b: 1px -2px; }", "-"},
+		{"a { b: 0 -$x; }", "-"},
+		{"a { b: -2px; }", "-"},
+		{"a { b: 1px - 2px; }", ""},
+		{"a { b: 1px-2px; }", ""},
+		{"a { b: (1)-(2); }", ""},
+	}
+	for _, test := range tests {
+		items, err := Tokens(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, item := range items {
+			if item.Type != token.SUB {
+				continue
+			}
+			found = true
+			if item.Value != test.lit {
+				t.Errorf("%q got: %q wanted: %q", test.in, item.Value, test.lit)
+			}
+		}
+		if !found {
+			t.Errorf("%q no - found", test.in)
+		}
+	}
+}
+
+func TestTokens_url(t *testing.T) {
+	tests := []struct {
+		in string
+		e  []Item
+	}{
+		{"a { b: url(a/b.png?v=2#c); }", []Item{
+			{token.STRING, 7, "url(a/b.png?v=2#c)"},
+		}},
+		{"a { b: url(x/#{$p}#c); }", []Item{
+			{token.STRING, 7, "url(x/"},
+			{token.INTERP, 13, "#{"},
+			{token.VAR, 15, "$p"},
+			{token.RBRACE, 17, ""},
+			{token.STRING, 18, "#c)"},
+		}},
+	}
+	for _, test := range tests {
+		items, err := Tokens(test.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// skip a { b :
+		items = items[5 : 5+len(test.e)]
+		for i := range test.e {
+			if items[i] != test.e[i] {
+				t.Errorf("%q %d got: %v wanted: %v", test.in, i, items[i], test.e[i])
+			}
+		}
+	}
+
+	// quoted urls and variables are a call to url()
+	items, err := Tokens(`a { b: url("a.png"); c: url($x); }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int
+	for _, item := range items {
+		if item.Type == token.IDENT && item.Value == "url" {
+			calls++
+		}
+	}
+	if calls != 2 {
+		t.Errorf("got: %d calls to url() wanted: 2", calls)
+	}
+}
+
+func TestTokens_error(t *testing.T) {
+	items, err := Tokens("div { a: \xff; }")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, item := range items {
+		if item.Type == token.EOF {
+			t.Error("EOF returned with error")
+		}
+	}
+}
+
+func TestScan_backup_invalid_utf8(t *testing.T) {
+	src := []byte("a\xffb")
+	var s Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), src,
+		func(token.Position, string) {}, 0)
+	s.next()
+	if s.ch != utf8.RuneError {
+		t.Fatalf("got: %q wanted: RuneError", s.ch)
+	}
+	s.backup()
+	if s.ch != 'a' || s.offset != 0 {
+		t.Fatalf("got: %q at %d wanted: 'a' at 0", s.ch, s.offset)
+	}
+	s.next()
+	s.next()
+	if s.ch != 'b' {
+		t.Fatalf("got: %q wanted: 'b'", s.ch)
+	}
+}
+
+func TestScan_eof(t *testing.T) {
+	src := []byte("ab")
+	var s Scanner
+	s.Init(token.NewFileSet().AddFile("", -1, len(src)), src, nil, 0)
+	s.next()
+	s.next()
+	s.next()
+	if s.ch != -1 || s.offset != len(src) {
+		t.Fatalf("got: %q at %d wanted: EOF at %d", s.ch, s.offset, len(src))
+	}
+	s.backup()
+	if s.ch != 'b' || s.offset != 1 {
+		t.Fatalf("got: %q at %d wanted: 'b' at 1", s.ch, s.offset)
+	}
+	s.next()
+	if s.ch != -1 || s.offset != len(src) {
+		t.Fatalf("got: %q at %d wanted: EOF at %d", s.ch, s.offset, len(src))
+	}
+}
+
+func TestTokens_eof(t *testing.T) {
+	for _, in := range []string{"", "$a: 1", "/* c */", "div { a: b }"} {
+		items, err := Tokens(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := items[len(items)-1]
+		if last.Type != token.EOF || last.Pos != len(in) {
+			t.Errorf("%q got: %v wanted: EOF at %d", in, last, len(in))
+		}
+	}
+
+	// a selector without a block must not scan forever
+	if _, err := Tokens("a"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestTokens_long_selector(t *testing.T) {
+	// every part of a selector list is queued before it is returned
+	var sels []string
+	for i := 0; i < 20; i++ {
+		sels = append(sels, fmt.Sprintf(".s%d > a", i))
+	}
+	in := strings.Join(sels, ", ") + " { b: c; }"
+	items, err := Tokens(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var strs int
+	for _, item := range items {
+		if item.Type == token.STRING {
+			strs++
+		}
+	}
+	// a and .sN for each selector plus the value
+	if e := 2*len(sels) + 1; strs != e {
+		t.Errorf("got: %d strings wanted: %d", strs, e)
+	}
+	if last := items[len(items)-1]; last.Type != token.EOF {
+		t.Errorf("got: %v wanted: EOF", last)
+	}
+}
+
+// a stylesheet exercising selectors, interpolation and media queries
+// which are scanned ahead and queued
+var benchSrc = strings.Repeat(`$width: 10px;
+@mixin box($w) { width: $w; height: #{$w}; }
+.nav > li + li ~ a:hover, #main .item[data-x="y"] {
+  margin: 0 auto;
+  padding: $width * 2 1px;
+  @include box(5px);
+  &:not(.active) .child { color: rgba(0, 0, 0, .5); }
+}
+.item-#{$width}, .b > .c { top: 0; }
+@media screen and (max-width: 100px) {
+  div p span { font: 12px/1.5 "Helvetica Neue", sans-serif; }
+}
+`, 200)
+
+func BenchmarkTokens(b *testing.B) {
+	defer func(t bool) { trace = t }(trace)
+	trace = false
+	b.SetBytes(int64(len(benchSrc)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Tokens(benchSrc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
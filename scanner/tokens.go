@@ -0,0 +1,40 @@
+package scanner
+
+import "github.com/wellington/sass/token"
+
+// Item is a single token found by Tokens
+type Item struct {
+	Type token.Token
+	// Pos is the byte offset of the token in src
+	Pos   int
+	Value string
+}
+
+// Tokens scans src and returns every token found including
+// comments. On success the last Item is always token.EOF. If the
+// scanner reports errors, the Items scanned so far are returned
+// along with an ErrorList describing them. No token.EOF Item is
+// appended in this case.
+func Tokens(src string) ([]Item, error) {
+	var errs ErrorList
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s Scanner
+	s.Init(file, []byte(src), errs.Add, ScanComments)
+
+	var items []Item
+	for {
+		pos, tok, lit := s.Scan()
+		if len(errs) > 0 {
+			return items, errs.Err()
+		}
+		items = append(items, Item{
+			Type:  tok,
+			Pos:   file.Offset(pos),
+			Value: lit,
+		})
+		if tok == token.EOF {
+			return items, nil
+		}
+	}
+}
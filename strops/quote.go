@@ -9,10 +9,46 @@ func Unquote(in string) string {
 	return unescape(in)
 }
 
+// Quote escapes double quotes found in, so that in may be wrapped
+// in double quotes
+func Quote(in string) string {
+	return strings.Replace(in, quote, sassEscape+quote, -1)
+}
+
+// UnescapeQuotes removes the escapes from quotes found in,
+// ie. it\'s => it's. Other escapes are preserved.
+func UnescapeQuotes(in string) string {
+	if !strings.Contains(in, sassEscape) {
+		return in
+	}
+	b := make([]byte, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		if in[i] == '\\' && i+1 < len(in) {
+			i++
+			if in[i] != '"' && in[i] != '\'' {
+				b = append(b, '\\')
+			}
+		}
+		b = append(b, in[i])
+	}
+	return string(b)
+}
+
+// Wrap surrounds in with quotes the way Sass prints strings. Double
+// quotes are preferred, single quotes are used when in contains
+// double quotes but no single quotes.
+func Wrap(in string) string {
+	if strings.Contains(in, quote) && !strings.Contains(in, squote) {
+		return squote + in + squote
+	}
+	return quote + Quote(in) + quote
+}
+
 const (
 	sassEscape = `\`
 	goEscape   = `\u`
 	quote      = `"`
+	squote     = `'`
 )
 
 // unquote converts Sass's bizarre unicode escape format to valid
@@ -26,3 +26,40 @@ func TestQuote_unescape(t *testing.T) {
 		}
 	}
 }
+
+func TestQuote(t *testing.T) {
+	if e, s := `a\"b`, Quote(`a"b`); s != e {
+		t.Errorf("got: %s wanted: %s", s, e)
+	}
+	if e, s := "hi", Quote("hi"); s != e {
+		t.Errorf("got: %s wanted: %s", s, e)
+	}
+}
+
+func TestUnescapeQuotes(t *testing.T) {
+	tests := []s{
+		{`it\'s \"a\"`, `it's "a"`},
+		{`\2014`, `\2014`},
+		{`a\\'`, `a\\'`},
+		{`a\\\"`, `a\\"`},
+	}
+	for _, tst := range tests {
+		if s := UnescapeQuotes(tst.a); s != tst.e {
+			t.Errorf("got: %s wanted: %s", s, tst.e)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []s{
+		{"hi", `"hi"`},
+		{"it's", `"it's"`},
+		{`say "hi"`, `'say "hi"'`},
+		{`it's "hi"`, `"it's \"hi\""`},
+	}
+	for _, tst := range tests {
+		if s := Wrap(tst.a); s != tst.e {
+			t.Errorf("got: %s wanted: %s", s, tst.e)
+		}
+	}
+}
@@ -25,11 +25,14 @@ const (
 	RULE
 	STRING    // word
 	COLOR     // #000
+	NULL      // null
+	BOOL      // true false
 	INTERP    // #{value}
 	VALUE     // value (rhs of rule)
 	ATTRIBUTE // [disabled] [type='button']
 	PSEUDO    // :first-child :nth-last-child
 	AND       // & backreference
+	IMPORTANT // !important
 	literal_end
 
 	cssnums_beg
@@ -128,6 +131,7 @@ const (
 	DEBUG  // @debug
 	WARN   // @warn
 	ERROR  // @error
+	ATRULE // generic at-rule ie. @supports
 	keyword_end
 
 	CMDVAR
@@ -162,12 +166,16 @@ var Tokens = [...]string{
 	QSTRING:  `quote`,
 	QSSTRING: `singlequote`,
 	COLOR:    "color",
+	NULL:     "null",
+	BOOL:     "bool",
 	INTERP:   "INTERPOLATION",
 	// Selector tokens
 	ATTRIBUTE: "attribute",
 	// BACKREF: "&",
 	PSEUDO: "pseudo-selector",
 
+	IMPORTANT: "!important",
+
 	TEXT:     "text",
 	SELECTOR: "selector",
 
@@ -214,6 +222,8 @@ var Tokens = [...]string{
 	GTR:    ">",
 	ASSIGN: "=",
 	NOT:    "!",
+	LAND:   "and",
+	LOR:    "or",
 
 	DOLLAR: "$",
 
@@ -254,6 +264,7 @@ var Tokens = [...]string{
 	DEBUG:  "@debug",
 	WARN:   "@warn",
 	ERROR:  "@error",
+	ATRULE: "@rule",
 
 	BKND: "background",
 	FIN:  "FINISHED",